@@ -28,20 +28,37 @@ func main() {
 	http.HandleFunc("/decoder", src.DecoderHandler)
 	http.HandleFunc("/ber", src.BERHandler)
 	http.HandleFunc("/autocorrelation", src.AutocorrelationHandler)
+	http.HandleFunc("/linear-complexity", src.LinearComplexityHandler)
+	http.HandleFunc("/randomness", src.RandomnessHandler)
 
 	// --- CDMA Simulation Handlers ---
 	http.HandleFunc("/cdma-simulate", src.CDMASimulateHandler)
-	http.HandleFunc("/cdma-system-config", src.CDMASystemConfigHandler)                // Module 1
-	http.HandleFunc("/cdma-transmitter-a-results", src.CDMATransmitterAResultsHandler) // Module 2A
-	http.HandleFunc("/cdma-transmitter-b-results", src.CDMATransmitterBResultsHandler) // Module 2B
-	http.HandleFunc("/cdma-channel-results", src.CDMAChannelResultsHandler)            // Module 3
-	http.HandleFunc("/cdma-receiver-a-results", src.CDMAReceiverAResultsHandler)       // Module 4A
-	http.HandleFunc("/cdma-receiver-b-results", src.CDMAReceiverBResultsHandler)       // Module 4B
-	http.HandleFunc("/cdma-ber-a-results", src.CDMABERAResultsHandler)                 // Module 5A
-	http.HandleFunc("/cdma-ber-b-results", src.CDMABERBResultsHandler)                 // Module 5B
-	http.HandleFunc("/cdma-code-analysis", src.CDMACodeAnalysisHandler)                // Module 6
+	http.HandleFunc("/cdma-system-config", src.CDMASystemConfigHandler)             // Module 1
+	http.HandleFunc("/cdma-transmitter-results", src.CDMATransmitterResultsHandler) // Module 2
+	http.HandleFunc("/cdma-channel-results", src.CDMAChannelResultsHandler)         // Module 3
+	http.HandleFunc("/cdma-receiver-results", src.CDMAReceiverResultsHandler)       // Module 4
+	http.HandleFunc("/cdma-ber-results", src.CDMABERResultsHandler)                 // Module 5
+	http.HandleFunc("/cdma-code-analysis", src.CDMACodeAnalysisHandler)             // Module 6
+	http.HandleFunc("/cdma-ber-sweep", src.CDMASweepHandler)                        // Module 7
+	http.HandleFunc("/cdma-ber-sweep-results", src.CDMASweepResultsHandler)         // Module 7
+	http.HandleFunc("/cdma-preferred-pairs", src.CDMAPreferredPairsHandler)         // Module 1 pair picker
+	http.HandleFunc("/cdma-gold-family", src.CDMAGoldFamilyHandler)                 // Module 1 code family
 	// --- END NEW ---
 
+	// --- Run History ---
+	http.HandleFunc("/history", src.HistoryHandler)
+	http.HandleFunc("/history/reopen", src.HistoryReopenHandler)
+	http.HandleFunc("/history/delete", src.HistoryDeleteHandler)
+	http.HandleFunc("/history/compare", src.HistoryCompareHandler)
+
+	// --- JSON API ---
+	http.HandleFunc("/api/v1/simulate", src.APISimulateHandler)
+	http.HandleFunc("/api/v1/cdma/simulate", src.APICDMASimulateHandler)
+	http.HandleFunc("/api/v1/cdma/sweep", src.APICDMASweepHandler)
+	http.HandleFunc("/api/v1/history/compare", src.APIHistoryCompareHandler)
+	http.HandleFunc("/api/v1/gold/preferred-pairs", src.APIPreferredPairsHandler)
+	http.HandleFunc("/api/v1/gold/family", src.APIGoldFamilyHandler)
+
 	// --- Start Server ---
 	port := ":8080"
 	fmt.Printf("Server starting on http://localhost%s\n", port)
@@ -0,0 +1,603 @@
+package src
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"runtime"
+	"time"
+	"unicode/utf8"
+
+	"github.com/BartiX259/BSO_Projekt/src/simulation"
+)
+
+// --- JSON API (v1) ---
+// The API mirrors the HTML forms, but instead of falling back to defaults on invalid
+// input it rejects the request with a list of field errors. Omitted fields use the form defaults.
+
+// SimulateRequest is the body of POST /api/v1/simulate
+type SimulateRequest struct {
+	SeqType           string   `json:"seqType"` // "random" (default), "random-text" or "text"
+	SeqText           string   `json:"seqText"`
+	SeqLength         int      `json:"seqLength"`
+	GoldN             int      `json:"goldN"`
+	GoldTaps1         []uint   `json:"goldTaps1"`
+	GoldTaps2         []uint   `json:"goldTaps2"`
+	CodeFamily        string   `json:"codeFamily"`        // "gold" (default), "kasami-small", "kasami-large", "walsh", "ovsf", "msequence", "barker" or "jpl"
+	ErrorType         string   `json:"errorType"`         // "random" (default), "burst", "exact", "gilbert-elliott" or "erasure"
+	ErrorRate         *float64 `json:"errorRate"`         // Percent, the erasure probability for "erasure", unused by "gilbert-elliott"
+	BurstLength       int      `json:"burstLength"`       // Fixed or mean burst length, default 3
+	BurstDistribution string   `json:"burstDistribution"` // "fixed" (default) or "geometric"
+	GEGoodToBad       *float64 `json:"geGoodToBad"`       // Gilbert-Elliott probabilities in percent
+	GEBadToGood       *float64 `json:"geBadToGood"`
+	GEGoodErrorRate   *float64 `json:"geGoodErrorRate"`
+	GEBadErrorRate    *float64 `json:"geBadErrorRate"`
+	InsertionRate     float64  `json:"insertionRate"` // Synchronization errors in percent, at most 50
+	DeletionRate      float64  `json:"deletionRate"`
+	SlipPeriod        int      `json:"slipPeriod"`      // Bits (chips in DSSS mode) between clock slips, 0 (default) for none
+	SlipDirection     string   `json:"slipDirection"`   // "drop" (default) or "repeat"
+	DecoderType       string   `json:"decoderType"`     // "xor", "majority", "soft" or "preamble", default "xor" when scrambling and "majority" for DSSS
+	CodePhaseOffset   int      `json:"codePhaseOffset"` // Chips the transmitter code leads the receiver code by
+	SpreadingMode     string   `json:"spreadingMode"`   // "scramble" (default) or "dsss"
+	SpreadingFactor   int      `json:"spreadingFactor"` // Chips per data bit in DSSS mode, 0 (default) for one code period
+	ErrorEnabled      *bool    `json:"errorEnabled"`
+	DecoderEnabled    *bool    `json:"decoderEnabled"`
+	BerEnabled        *bool    `json:"berEnabled"`
+	AutocorrEnabled   *bool    `json:"autocorrEnabled"`
+	ComplexityEnabled *bool    `json:"complexityEnabled"`
+	RandomnessEnabled *bool    `json:"randomnessEnabled"`
+	Seed              *int64   `json:"seed"`
+	RequirePrimitive  bool     `json:"requirePrimitive"` // Reject taps that do not give a maximal-length sequence
+}
+
+// CDMASimulateRequest is the body of POST /api/v1/cdma/simulate
+type CDMASimulateRequest struct {
+	GoldN            int                  `json:"goldN"`
+	GoldTaps1        []uint               `json:"goldTaps1"`
+	GoldTaps2        []uint               `json:"goldTaps2"`
+	CodeFamily       string               `json:"codeFamily"` // "gold" (default), "kasami-small", "kasami-large", "walsh", "ovsf", "msequence", "barker" or "jpl"
+	Users            []CDMAUserRequest    `json:"users"`
+	SeqLengthRandom  int                  `json:"seqLengthRandom"` // Bytes of random data for users without text
+	NoiseLevel       *float64             `json:"noiseLevel"`      // Percent
+	Seed             *int64               `json:"seed"`
+	RequirePrimitive bool                 `json:"requirePrimitive"` // Reject taps that do not give a maximal-length sequence
+	Sweep            *CDMASweepParameters `json:"sweep,omitempty"`  // Only used by /api/v1/cdma/sweep
+}
+
+// CDMAUserRequest configures one transmitter of a CDMA request
+type CDMAUserRequest struct {
+	Text            string   `json:"text"`
+	Seed1           uint64   `json:"seed1"`
+	Seed2           uint64   `json:"seed2"`
+	CodeIndex       *int     `json:"codeIndex"`       // Code set index, replaces the seeds when set
+	SpreadingFactor int      `json:"spreadingFactor"` // OVSF only, a power of two up to 2^goldN (default)
+	Delay           int      `json:"delay"`           // Chips, 0 for a synchronous user
+	Power           *float64 `json:"power"`
+}
+
+// CDMASweepParameters configures the noise axis and stopping rule of POST /api/v1/cdma/sweep
+type CDMASweepParameters struct {
+	Mode         string  `json:"mode"` // "ebn0" (default) or "sigma"
+	Start        float64 `json:"start"`
+	Stop         float64 `json:"stop"`
+	Step         float64 `json:"step"`
+	BitsPerTrial int     `json:"bitsPerTrial"`
+	TargetErrors int     `json:"targetErrors"`
+	MaxBits      int     `json:"maxBits"`
+	Workers      int     `json:"workers"`
+}
+
+// APIError is the JSON body of every failed API request
+type APIError struct {
+	Error  string       `json:"error"`
+	Fields []FieldError `json:"fields,omitempty"`
+}
+
+// FieldError describes one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// fieldErrors collects validation errors of a request
+type fieldErrors []FieldError
+
+func (e *fieldErrors) add(field, format string, args ...any) {
+	*e = append(*e, FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// APISimulateHandler runs the general pipeline and returns the full SimulationResults as JSON
+func APISimulateHandler(w http.ResponseWriter, r *http.Request) {
+	var req SimulateRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+
+	params, errs := req.validate()
+	if len(errs) > 0 {
+		writeAPIValidationError(w, errs)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, runGeneralSimulation(params))
+}
+
+// APICDMASimulateHandler runs the CDMA simulation and returns the full CDMAResult as JSON
+func APICDMASimulateHandler(w http.ResponseWriter, r *http.Request) {
+	var req CDMASimulateRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	goldN, taps1, taps2, family, users := req.validateSystem(&errs)
+	seqLengthRandom := 1
+	if req.SeqLengthRandom != 0 {
+		seqLengthRandom = req.SeqLengthRandom
+		if seqLengthRandom < 1 || seqLengthRandom > 10 {
+			errs.add("seqLengthRandom", "must be between 1 and 10 bytes")
+		}
+	}
+	noiseLevelPercent := 100.0
+	if req.NoiseLevel != nil {
+		noiseLevelPercent = *req.NoiseLevel
+		if noiseLevelPercent < 0 {
+			errs.add("noiseLevel", "must not be negative")
+		}
+	}
+	if len(errs) == 0 && cdmaChipCount(family, goldN, users, seqLengthRandom*8) > maxSpreadChips {
+		errs.add("users", "would send %d chips, at most %d are allowed", cdmaChipCount(family, goldN, users, seqLengthRandom*8), maxSpreadChips)
+	}
+	if len(errs) > 0 {
+		writeAPIValidationError(w, errs)
+		return
+	}
+
+	result := simulation.SimulateCDMA(goldN, taps1, taps2, family, users, seqLengthRandom*8, noiseLevelPercent/100.0, seedOrNow(req.Seed))
+	result.NoiseLevel = noiseLevelPercent
+	writeJSON(w, http.StatusOK, result)
+}
+
+// APICDMASweepHandler runs a Monte Carlo BER sweep and returns the SweepResult as JSON
+func APICDMASweepHandler(w http.ResponseWriter, r *http.Request) {
+	var req CDMASimulateRequest
+	if !decodeAPIRequest(w, r, &req) {
+		return
+	}
+
+	var errs fieldErrors
+	goldN, taps1, taps2, family, users := req.validateSystem(&errs)
+	sweep := CDMASweepParameters{Mode: simulation.SweepModeEbN0, Stop: 10, Step: 1, BitsPerTrial: 100, TargetErrors: 100, MaxBits: 100000}
+	if req.Sweep != nil {
+		sweep = *req.Sweep
+		if sweep.Mode == "" {
+			sweep.Mode = simulation.SweepModeEbN0
+		}
+	} else {
+		errs.add("sweep", "is required")
+	}
+	if sweep.Mode != simulation.SweepModeEbN0 && sweep.Mode != simulation.SweepModeSigma {
+		errs.add("sweep.mode", "must be %q or %q", simulation.SweepModeEbN0, simulation.SweepModeSigma)
+	}
+	if sweep.Mode == simulation.SweepModeSigma && sweep.Start <= 0 {
+		errs.add("sweep.start", "noise sigma must be positive")
+	}
+	if sweep.Step <= 0 {
+		errs.add("sweep.step", "must be positive")
+	}
+	if sweep.Stop < sweep.Start {
+		errs.add("sweep.stop", "must not be lower than start")
+	}
+	if sweep.BitsPerTrial < 1 || sweep.BitsPerTrial > 10000 {
+		errs.add("sweep.bitsPerTrial", "must be between 1 and 10000")
+	}
+	if sweep.TargetErrors < 0 {
+		errs.add("sweep.targetErrors", "must not be negative")
+	}
+	if sweep.MaxBits < 1 || sweep.MaxBits > cdmaSweepMaxBits {
+		errs.add("sweep.maxBits", "must be between 1 and %d", cdmaSweepMaxBits)
+	}
+	if sweep.Workers < 0 || sweep.Workers > cdmaSweepMaxWorkers {
+		errs.add("sweep.workers", "must be between 0 (all CPUs) and %d", cdmaSweepMaxWorkers)
+	}
+	if sweep.Workers == 0 {
+		sweep.Workers = runtime.NumCPU()
+	}
+
+	config := simulation.SweepConfig{
+		N:            goldN,
+		Poly1:        taps1,
+		Poly2:        taps2,
+		Family:       family,
+		Users:        users,
+		Mode:         sweep.Mode,
+		Start:        sweep.Start,
+		Stop:         sweep.Stop,
+		Step:         sweep.Step,
+		BitsPerTrial: sweep.BitsPerTrial,
+		TargetErrors: sweep.TargetErrors,
+		MaxBits:      sweep.MaxBits,
+		Workers:      sweep.Workers,
+		Seed:         seedOrNow(req.Seed),
+	}
+	if len(errs) == 0 && len(config.Values()) > cdmaSweepMaxPoints {
+		errs.add("sweep", "at most %d noise points are allowed", cdmaSweepMaxPoints)
+	} else if len(errs) == 0 && config.MaxChips() > cdmaSweepMaxChips {
+		errs.add("sweep", "would send up to %d chips, at most %d are allowed", config.MaxChips(), cdmaSweepMaxChips)
+	}
+	if len(errs) > 0 {
+		writeAPIValidationError(w, errs)
+		return
+	}
+
+	result, err := simulation.SweepCDMABER(r.Context(), config)
+	if err != nil {
+		log.Printf("API CDMA sweep stopped: %v", err)
+		return
+	}
+	writeJSON(w, http.StatusOK, result)
+}
+
+// validate checks the request and fills in the form defaults for omitted fields
+func (req SimulateRequest) validate() (generalSimParams, fieldErrors) {
+	var errs fieldErrors
+	params := generalSimParams{
+		SeqType:           req.SeqType,
+		SeqText:           req.SeqText,
+		SeqLength:         64,
+		GoldN:             10,
+		GoldTaps1:         []uint{0, 3},
+		GoldTaps2:         []uint{0, 2, 3, 8},
+		CodeFamily:        simulation.CodeFamilyGold,
+		ErrorType:         simulation.ErrorModelRandom,
+		ErrorRate:         5.0,
+		BurstLength:       simulation.DefaultBurstLength,
+		BurstDistribution: simulation.BurstFixed,
+		GEGoodToBad:       defaultGEGoodToBad,
+		GEBadToGood:       defaultGEBadToGood,
+		GEGoodErrorRate:   defaultGEGoodErrorRate,
+		GEBadErrorRate:    defaultGEBadErrorRate,
+		SpreadingMode:     simulation.SpreadingModeScramble,
+		ErrorEnabled:      boolOrDefault(req.ErrorEnabled, true),
+		DecoderEnabled:    boolOrDefault(req.DecoderEnabled, true),
+		BerEnabled:        boolOrDefault(req.BerEnabled, true),
+		AutocorrEnabled:   boolOrDefault(req.AutocorrEnabled, true),
+		ComplexityEnabled: boolOrDefault(req.ComplexityEnabled, true),
+		RandomnessEnabled: boolOrDefault(req.RandomnessEnabled, true),
+		Seed:              seedOrNow(req.Seed),
+	}
+
+	switch req.SeqType {
+	case "":
+		params.SeqType = "random"
+	case "random", "random-text":
+	case "text":
+		if req.SeqText == "" {
+			errs.add("seqText", "is required when seqType is \"text\"")
+		}
+	default:
+		errs.add("seqType", "must be \"random\", \"random-text\" or \"text\"")
+	}
+	if req.SeqLength != 0 {
+		params.SeqLength = req.SeqLength
+		if req.SeqLength < 1 || req.SeqLength > maxSeqLength {
+			errs.add("seqLength", "must be between 1 and %d", maxSeqLength)
+		}
+	}
+	if req.GoldN != 0 {
+		params.GoldN = req.GoldN
+		if req.GoldN < 2 || req.GoldN > 16 {
+			errs.add("goldN", "must be between 2 and 16")
+		}
+	}
+	if req.GoldTaps1 != nil {
+		params.GoldTaps1 = req.GoldTaps1
+	}
+	if req.GoldTaps2 != nil {
+		params.GoldTaps2 = req.GoldTaps2
+	}
+	if req.CodeFamily != "" {
+		params.CodeFamily = req.CodeFamily
+	}
+	validateCodeFamily(&errs, params.CodeFamily, uint(params.GoldN))
+	if simulation.CodeFamilyUsesLFSR1(params.CodeFamily) {
+		validateTaps(&errs, "goldTaps1", params.GoldTaps1, uint(params.GoldN))
+	}
+	if simulation.CodeFamilyUsesSeeds(params.CodeFamily) {
+		validateTaps(&errs, "goldTaps2", params.GoldTaps2, uint(params.GoldN))
+	}
+	switch req.ErrorType {
+	case "":
+	case simulation.ErrorModelRandom, simulation.ErrorModelBurst, simulation.ErrorModelExact, simulation.ErrorModelGilbertElliott, simulation.ErrorModelErasure:
+		params.ErrorType = req.ErrorType
+	default:
+		errs.add("errorType", "must be %q, %q, %q, %q or %q", simulation.ErrorModelRandom, simulation.ErrorModelBurst, simulation.ErrorModelExact, simulation.ErrorModelGilbertElliott, simulation.ErrorModelErasure)
+	}
+	if req.BurstLength != 0 {
+		params.BurstLength = req.BurstLength
+		if req.BurstLength < 1 {
+			errs.add("burstLength", "must be positive")
+		}
+	}
+	switch req.BurstDistribution {
+	case "":
+	case simulation.BurstFixed, simulation.BurstGeometric:
+		params.BurstDistribution = req.BurstDistribution
+	default:
+		errs.add("burstDistribution", "must be %q or %q", simulation.BurstFixed, simulation.BurstGeometric)
+	}
+	percentOrDefault(&errs, "geGoodToBad", req.GEGoodToBad, &params.GEGoodToBad)
+	percentOrDefault(&errs, "geBadToGood", req.GEBadToGood, &params.GEBadToGood)
+	percentOrDefault(&errs, "geGoodErrorRate", req.GEGoodErrorRate, &params.GEGoodErrorRate)
+	percentOrDefault(&errs, "geBadErrorRate", req.GEBadErrorRate, &params.GEBadErrorRate)
+	params.InsertionRate = req.InsertionRate
+	if req.InsertionRate < 0 || req.InsertionRate > maxSyncErrorRate {
+		errs.add("insertionRate", "must be between 0 and %g percent", maxSyncErrorRate)
+	}
+	params.DeletionRate = req.DeletionRate
+	if req.DeletionRate < 0 || req.DeletionRate > maxSyncErrorRate {
+		errs.add("deletionRate", "must be between 0 and %g percent", maxSyncErrorRate)
+	}
+	params.SlipPeriod = req.SlipPeriod
+	if req.SlipPeriod < 0 {
+		errs.add("slipPeriod", "must not be negative")
+	}
+	switch req.SlipDirection {
+	case "", "drop":
+	case "repeat":
+		params.SlipRepeat = true
+	default:
+		errs.add("slipDirection", "must be \"drop\" or \"repeat\"")
+	}
+	if req.ErrorRate != nil {
+		params.ErrorRate = *req.ErrorRate
+		if params.ErrorRate < 0 || params.ErrorRate > 100 {
+			errs.add("errorRate", "must be between 0 and 100 percent")
+		}
+	}
+	if _, ok := simulation.LookupDecoder(req.DecoderType); ok {
+		params.DecoderType = req.DecoderType
+	} else if req.DecoderType != "" {
+		errs.add("decoderType", "must be one of %q", simulation.DecoderTypes())
+	}
+	params.CodePhaseOffset = req.CodePhaseOffset
+	switch req.SpreadingMode {
+	case "":
+	case simulation.SpreadingModeScramble, simulation.SpreadingModeDSSS:
+		params.SpreadingMode = req.SpreadingMode
+	default:
+		errs.add("spreadingMode", "must be %q or %q", simulation.SpreadingModeScramble, simulation.SpreadingModeDSSS)
+	}
+	params.SpreadingFactor = req.SpreadingFactor
+	if req.SpreadingFactor < 0 {
+		errs.add("spreadingFactor", "must not be negative")
+	}
+
+	if params.SeqType == "random-text" && params.SeqLength/8 == 0 {
+		errs.add("seqLength", "must be at least 8 for random text")
+	}
+	if params.SeqType == "text" && 8*len(params.SeqText) > maxSeqLength {
+		errs.add("seqText", "must not be longer than %d bytes", maxSeqLength/8)
+	}
+	if params.SpreadingMode == simulation.SpreadingModeDSSS && len(errs) == 0 {
+		if chips := generalChipCount(params); chips > maxSpreadChips {
+			errs.add("spreadingFactor", "spreads the data to %d chips, at most %d are allowed", chips, maxSpreadChips)
+		}
+	}
+	if req.RequirePrimitive && len(errs) == 0 {
+		seed1, seed2 := generalGoldSeeds(params.GoldN)
+		if simulation.CodeFamilyUsesSeeds(params.CodeFamily) {
+			validatePrimitiveTaps(&errs, "goldTaps1", uint(params.GoldN), params.GoldTaps1, []uint64{seed1})
+			validatePrimitiveTaps(&errs, "goldTaps2", uint(params.GoldN), params.GoldTaps2, []uint64{seed2})
+		} else if simulation.CodeFamilyUsesLFSR1(params.CodeFamily) {
+			validatePrimitiveTaps(&errs, "goldTaps1", uint(params.GoldN), params.GoldTaps1, []uint64{1})
+		}
+	}
+	return params, errs
+}
+
+// validateSystem checks the code configuration and the users of a CDMA request
+func (req CDMASimulateRequest) validateSystem(errs *fieldErrors) (uint, []uint, []uint, string, []simulation.CDMAUserConfig) {
+	goldN := uint(4)
+	if req.GoldN != 0 {
+		goldN = uint(req.GoldN)
+		if req.GoldN < 2 || req.GoldN > 16 {
+			errs.add("goldN", "must be between 2 and 16")
+		}
+	}
+	taps1 := []uint{0, 3}
+	if req.GoldTaps1 != nil {
+		taps1 = req.GoldTaps1
+	}
+	taps2 := []uint{0, 2, 3}
+	if req.GoldTaps2 != nil {
+		taps2 = req.GoldTaps2
+	}
+	family := simulation.CodeFamilyGold
+	if req.CodeFamily != "" {
+		family = req.CodeFamily
+	}
+	validateCodeFamily(errs, family, goldN)
+	usesSeeds := simulation.CodeFamilyUsesSeeds(family)
+	usesLFSR1 := simulation.CodeFamilyUsesLFSR1(family)
+	if usesLFSR1 {
+		validateTaps(errs, "goldTaps1", taps1, goldN)
+	}
+	if usesSeeds {
+		validateTaps(errs, "goldTaps2", taps2, goldN)
+	}
+
+	if len(req.Users) == 0 {
+		errs.add("users", "at least one user is required")
+	}
+	if len(req.Users) > cdmaMaxUsers {
+		errs.add("users", "at most %d users are allowed", cdmaMaxUsers)
+	}
+	maxSeed := uint64(1)<<min(goldN, 16) - 1
+	familySize := simulation.CodeFamilySize(family, min(goldN, 16))
+	users := make([]simulation.CDMAUserConfig, len(req.Users))
+	for i, user := range req.Users {
+		users[i] = simulation.CDMAUserConfig{Seed1: user.Seed1, Seed2: user.Seed2, Text: user.Text, Power: 1.0}
+		if user.CodeIndex != nil {
+			users[i].UseCodeIndex = true
+			users[i].CodeIndex = *user.CodeIndex
+			users[i].Seed1, users[i].Seed2 = 1, 1
+			if familySize > 0 && (*user.CodeIndex < 0 || *user.CodeIndex >= familySize) {
+				errs.add(fmt.Sprintf("users[%d].codeIndex", i), "must be between 0 and %d", familySize-1)
+			}
+		} else if usesSeeds {
+			if user.Seed1 < 1 || user.Seed1 > maxSeed {
+				errs.add(fmt.Sprintf("users[%d].seed1", i), "must be between 1 and %d", maxSeed)
+			}
+			if user.Seed2 < 1 || user.Seed2 > maxSeed {
+				errs.add(fmt.Sprintf("users[%d].seed2", i), "must be between 1 and %d", maxSeed)
+			}
+		}
+		if user.SpreadingFactor != 0 {
+			sf := user.SpreadingFactor
+			if family != simulation.CodeFamilyOVSF {
+				errs.add(fmt.Sprintf("users[%d].spreadingFactor", i), "is only supported by the %q code family", simulation.CodeFamilyOVSF)
+			} else if sf < 1 || sf > 1<<min(goldN, 16) || sf&(sf-1) != 0 {
+				errs.add(fmt.Sprintf("users[%d].spreadingFactor", i), "must be a power of two between 1 and %d", 1<<min(goldN, 16))
+			}
+			users[i].SpreadingFactor = sf
+		}
+		users[i].Delay = user.Delay
+		if user.Delay < 0 || user.Delay > 1<<min(goldN, 16) {
+			errs.add(fmt.Sprintf("users[%d].delay", i), "must be between 0 and %d chips", 1<<min(goldN, 16))
+		}
+		if user.Power != nil {
+			users[i].Power = *user.Power
+			if *user.Power <= 0 {
+				errs.add(fmt.Sprintf("users[%d].power", i), "must be positive")
+			}
+		}
+		if utf8.RuneCountInString(user.Text) > cdmaMaxTextLength {
+			errs.add(fmt.Sprintf("users[%d].text", i), "must be at most %d characters", cdmaMaxTextLength)
+		}
+	}
+	if req.RequirePrimitive && len(*errs) == 0 {
+		seeds1 := make([]uint64, len(users))
+		seeds2 := make([]uint64, len(users))
+		for i, user := range users {
+			seeds1[i], seeds2[i] = user.Seed1, user.Seed2
+		}
+		if usesSeeds {
+			validatePrimitiveTaps(errs, "goldTaps1", goldN, taps1, seeds1)
+			validatePrimitiveTaps(errs, "goldTaps2", goldN, taps2, seeds2)
+		} else if usesLFSR1 {
+			validatePrimitiveTaps(errs, "goldTaps1", goldN, taps1, []uint64{1})
+		}
+	}
+	return goldN, taps1, taps2, family, users
+}
+
+// validateCodeFamily checks that the code family is known and can be built from n-bit registers
+func validateCodeFamily(errs *fieldErrors, family string, n uint) {
+	switch family {
+	case simulation.CodeFamilyGold, simulation.CodeFamilyKasamiSmall, simulation.CodeFamilyKasamiLarge,
+		simulation.CodeFamilyWalsh, simulation.CodeFamilyOVSF, simulation.CodeFamilyMSequence,
+		simulation.CodeFamilyBarker, simulation.CodeFamilyJPL:
+	default:
+		errs.add("codeFamily", "must be one of %q, %q, %q, %q, %q, %q, %q or %q", simulation.CodeFamilyGold,
+			simulation.CodeFamilyKasamiSmall, simulation.CodeFamilyKasamiLarge, simulation.CodeFamilyWalsh,
+			simulation.CodeFamilyOVSF, simulation.CodeFamilyMSequence, simulation.CodeFamilyBarker, simulation.CodeFamilyJPL)
+		return
+	}
+	if !simulation.CodeFamilySupported(family, n) {
+		switch family {
+		case simulation.CodeFamilyKasamiSmall:
+			errs.add("codeFamily", "the small Kasami set requires an even goldN")
+		case simulation.CodeFamilyKasamiLarge:
+			errs.add("codeFamily", "the large Kasami set requires goldN ≡ 2 (mod 4)")
+		case simulation.CodeFamilyJPL:
+			errs.add("codeFamily", "JPL codes require goldN of at most 12")
+		}
+	}
+}
+
+// validateTaps checks that the tap list is non-empty and every tap fits in an n-bit register
+func validateTaps(errs *fieldErrors, field string, taps []uint, n uint) {
+	if len(taps) == 0 {
+		errs.add(field, "at least one tap is required")
+	}
+	for _, t := range taps {
+		if t >= n {
+			errs.add(field, "tap %d exceeds the register length %d", t, n)
+		}
+	}
+}
+
+// validatePrimitiveTaps rejects taps whose feedback polynomial is not primitive, reporting the
+// shortest period obtained from the seeds. The taps must already be valid for the register length.
+func validatePrimitiveTaps(errs *fieldErrors, field string, n uint, taps []uint, seeds []uint64) {
+	check := simulation.CheckTaps(n, taps, seeds[0])
+	if check.Primitive {
+		return
+	}
+	period := check.Period
+	for _, seed := range seeds[1:] {
+		period = min(period, simulation.LFSRPeriod(n, taps, seed))
+	}
+	errs.add(field, "polynomial %s is not primitive: period %d instead of %d, e.g. taps %v give a maximal-length sequence",
+		check.Polynomial, period, check.MaxPeriod, simulation.PrimitiveTaps(n))
+}
+
+// decodeAPIRequest decodes a JSON request body, writing an error response on failure
+func decodeAPIRequest(w http.ResponseWriter, r *http.Request, dst any) bool {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, APIError{Error: "method not allowed, use POST"})
+		return false
+	}
+	decoder := json.NewDecoder(r.Body)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(dst); err != nil {
+		writeJSON(w, http.StatusBadRequest, APIError{Error: fmt.Sprintf("invalid JSON body: %v", err)})
+		return false
+	}
+	return true
+}
+
+func writeAPIValidationError(w http.ResponseWriter, errs fieldErrors) {
+	writeJSON(w, http.StatusUnprocessableEntity, APIError{Error: "validation failed", Fields: errs})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("Error encoding API response: %v", err)
+		http.Error(w, `{"error":"internal error while encoding the response"}`, http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// percentOrDefault stores an optional probability in percent, reporting values outside 0..100
+func percentOrDefault(errs *fieldErrors, field string, val *float64, dst *float64) {
+	if val == nil {
+		return
+	}
+	*dst = *val
+	if *val < 0 || *val > 100 {
+		errs.add(field, "must be between 0 and 100 percent")
+	}
+}
+
+func boolOrDefault(val *bool, defaultVal bool) bool {
+	if val == nil {
+		return defaultVal
+	}
+	return *val
+}
+
+// seedOrNow returns the requested master seed, or a fresh time-based one
+func seedOrNow(seed *int64) int64 {
+	if seed == nil {
+		return time.Now().UnixNano()
+	}
+	return *seed
+}
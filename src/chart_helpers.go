@@ -0,0 +1,159 @@
+package src
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Drawing area of the SVG charts rendered by templates/chart.html
+const (
+	chartWidth        = 320
+	chartHeight       = 200
+	chartMarginLeft   = 44
+	chartMarginRight  = 8
+	chartMarginTop    = 8
+	chartMarginBottom = 28
+)
+
+var chartColors = []string{"#3b82f6", "#e57373", "#059669", "#f6af65", "#8b5cf6", "#64748b", "#ec4899", "#0ea5e9"}
+
+// ChartSeries is a single line of a chart in data coordinates
+type ChartSeries struct {
+	Label string
+	X     []float64
+	Y     []float64
+}
+
+// ChartData holds everything templates/chart.html needs to draw an SVG line chart
+type ChartData struct {
+	Width      int
+	Height     int
+	PlotLeft   int
+	PlotTop    int
+	PlotRight  int
+	PlotBottom int
+	PlotWidth  int
+	PlotHeight int
+	YTickX     int // Right edge of the y tick labels
+	XTickY     int // Baseline of the x tick labels
+	XLabelY    int // Baseline of the x axis label
+	XLabel     string
+	YLabel     string
+	Series     []ChartSeriesData
+	XTicks     []ChartTick
+	YTicks     []ChartTick
+}
+
+// ChartSeriesData is a single line of a chart in SVG coordinates
+type ChartSeriesData struct {
+	Label  string
+	Color  string
+	Points string // "x,y x,y ..." for an SVG polyline
+}
+
+// ChartTick is an axis tick in SVG coordinates
+type ChartTick struct {
+	Pos   float64
+	Label string
+}
+
+// buildLineChart scales the series into the chart area. With logY the y axis is
+// logarithmic and non-positive values (e.g. BER of 0) are left out.
+func buildLineChart(series []ChartSeries, xLabel, yLabel string, logY bool) *ChartData {
+	chart := &ChartData{
+		Width:      chartWidth,
+		Height:     chartHeight,
+		PlotLeft:   chartMarginLeft,
+		PlotTop:    chartMarginTop,
+		PlotRight:  chartWidth - chartMarginRight,
+		PlotBottom: chartHeight - chartMarginBottom,
+		PlotWidth:  chartWidth - chartMarginRight - chartMarginLeft,
+		PlotHeight: chartHeight - chartMarginBottom - chartMarginTop,
+		YTickX:     chartMarginLeft - 4,
+		XTickY:     chartHeight - chartMarginBottom + 12,
+		XLabelY:    chartHeight - 2,
+		XLabel:     xLabel,
+		YLabel:     yLabel,
+	}
+
+	yValue := func(y float64) (float64, bool) {
+		if logY {
+			if y <= 0 {
+				return 0, false
+			}
+			return math.Log10(y), true
+		}
+		return y, true
+	}
+
+	minX, maxX := math.Inf(1), math.Inf(-1)
+	minY, maxY := math.Inf(1), math.Inf(-1)
+	for _, s := range series {
+		for i := range s.X {
+			y, ok := yValue(s.Y[i])
+			if !ok {
+				continue
+			}
+			minX, maxX = math.Min(minX, s.X[i]), math.Max(maxX, s.X[i])
+			minY, maxY = math.Min(minY, y), math.Max(maxY, y)
+		}
+	}
+	if math.IsInf(minX, 1) {
+		return chart
+	}
+	if logY {
+		minY, maxY = math.Floor(minY), math.Ceil(maxY)
+	}
+	if maxX == minX {
+		minX, maxX = minX-1, maxX+1
+	}
+	if maxY == minY {
+		minY, maxY = minY-1, maxY+1
+	}
+
+	plotW := float64(chart.PlotRight - chart.PlotLeft)
+	plotH := float64(chart.PlotBottom - chart.PlotTop)
+	scaleX := func(x float64) float64 { return float64(chart.PlotLeft) + (x-minX)/(maxX-minX)*plotW }
+	scaleY := func(y float64) float64 { return float64(chart.PlotBottom) - (y-minY)/(maxY-minY)*plotH }
+
+	for i, s := range series {
+		var sb strings.Builder
+		for j := range s.X {
+			y, ok := yValue(s.Y[j])
+			if !ok {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf("%.1f,%.1f ", scaleX(s.X[j]), scaleY(y)))
+		}
+		chart.Series = append(chart.Series, ChartSeriesData{
+			Label:  s.Label,
+			Color:  chartColors[i%len(chartColors)],
+			Points: strings.TrimSpace(sb.String()),
+		})
+	}
+
+	for i := 0; i <= 4; i++ {
+		x := minX + float64(i)*(maxX-minX)/4
+		chart.XTicks = append(chart.XTicks, ChartTick{Pos: scaleX(x), Label: formatChartValue(x)})
+	}
+	if logY {
+		for y := minY; y <= maxY; y++ {
+			chart.YTicks = append(chart.YTicks, ChartTick{Pos: scaleY(y), Label: fmt.Sprintf("1e%d", int(y))})
+		}
+	} else {
+		for i := 0; i <= 4; i++ {
+			y := minY + float64(i)*(maxY-minY)/4
+			chart.YTicks = append(chart.YTicks, ChartTick{Pos: scaleY(y), Label: formatChartValue(y)})
+		}
+	}
+	return chart
+}
+
+// Helper formatting an axis value compactly
+func formatChartValue(v float64) string {
+	if v == math.Trunc(v) && math.Abs(v) < 1e6 {
+		return fmt.Sprintf("%d", int(v))
+	}
+	return fmt.Sprintf("%.2g", v)
+}
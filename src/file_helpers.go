@@ -54,9 +54,24 @@ func FormatSimulationResultsToText(results *SimulationResults) string {
 	sb.WriteString(fmt.Sprintf("  Gold Code N: %d\n", results.GoldN))
 	sb.WriteString(fmt.Sprintf("  Gold Taps1: %v\n", results.GoldTaps1))
 	sb.WriteString(fmt.Sprintf("  Gold Taps2: %v\n", results.GoldTaps2))
+	sb.WriteString(fmt.Sprintf("  Code Family: %s\n", results.CodeFamily))
+	sb.WriteString(fmt.Sprintf("  Code: %s\n", results.CodeName))
 	sb.WriteString(fmt.Sprintf("  Decoder Type: %s\n", results.DecoderType))
+	sb.WriteString(fmt.Sprintf("  Code Phase Offset: %d chips\n", results.CodePhaseOffset))
+	if results.SpreadingMode == simulation.SpreadingModeDSSS {
+		sb.WriteString(fmt.Sprintf("  Spreading: DSSS, SF = %d chips/bit, processing gain %.2f dB\n", results.SpreadingFactor, simulation.ProcessingGain(results.SpreadingFactor)))
+	} else {
+		sb.WriteString("  Spreading: scramble (1 chip/bit)\n")
+	}
 	sb.WriteString(fmt.Sprintf("  Error Type: %s\n", results.ErrorType))
 	sb.WriteString(fmt.Sprintf("  Error Rate: %.2f%%\n", results.ErrorRate))
+	if results.ErrorModel.Type != "" {
+		sb.WriteString(fmt.Sprintf("  Error Model: %s\n", results.ErrorModel))
+	}
+	if results.SyncImpairment.Active() {
+		sb.WriteString(fmt.Sprintf("  Synchronization Errors: %s\n", results.SyncImpairment))
+	}
+	sb.WriteString(fmt.Sprintf("  Master Seed: %d\n", results.Seed))
 	sb.WriteString("\nGenerated/Processed Sequences:\n")
 	if results.Original != nil {
 		sb.WriteString(fmt.Sprintf("  Original (len %d): %s\n", results.Original.Len(), results.Original.String()))
@@ -79,11 +94,21 @@ func FormatSimulationResultsToText(results *SimulationResults) string {
 	if results.Corrupted != nil {
 		sb.WriteString(fmt.Sprintf("  Corrupted (len %d): %s\n", results.Corrupted.Len(), results.Corrupted.String()))
 		sb.WriteString(fmt.Sprintf("  Errors Introduced: %d\n", results.ErrorsIntroduced))
+		if results.CorruptedErased != nil {
+			sb.WriteString(fmt.Sprintf("  Erasures Introduced: %d\n", results.ErasuresIntroduced))
+			sb.WriteString(fmt.Sprintf("  Corrupted with Erasures: %s\n", simulation.MarkErasures(results.Corrupted, results.CorruptedErased)))
+		}
+		if results.SyncImpairment.Active() {
+			sb.WriteString(fmt.Sprintf("  Sync Errors Introduced: %d insertions, %d deletions, %d slips\n", results.SyncStats.Insertions, results.SyncStats.Deletions, results.SyncStats.Slips))
+		}
 	} else {
 		sb.WriteString("  Corrupted Sequence: Not available / Error module disabled\n")
 	}
 	if results.Decoded != nil {
 		sb.WriteString(fmt.Sprintf("  Decoded (len %d): %s\n", results.Decoded.Len(), results.Decoded.String()))
+		if results.DecodedErased != nil {
+			sb.WriteString(fmt.Sprintf("  Decoded with Erasures: %s\n", simulation.MarkErasures(results.Decoded, results.DecodedErased)))
+		}
 		if results.InputText != "" {
 			sb.WriteString(fmt.Sprintf("  Decoded ASCII: %s\n", bitsToASCII(results.Decoded.String())))
 		}
@@ -94,17 +119,77 @@ func FormatSimulationResultsToText(results *SimulationResults) string {
 	if results.Original != nil && results.Decoded != nil {
 		sb.WriteString(fmt.Sprintf("  BER: %.4f (%.2f%%)\n", results.BER, results.BER*100))
 		sb.WriteString(fmt.Sprintf("  Error Count (vs Original): %d / %d bits\n", results.ErrorCount, results.Original.Len()))
+		if results.DecodedErased != nil {
+			sb.WriteString(fmt.Sprintf("  Erased Bits (not counted as errors): %d (%.2f%%)\n", results.ErasureCount, 100*float64(results.ErasureCount)/float64(results.Original.Len())))
+		}
+		if results.SpreadingMode == simulation.SpreadingModeDSSS {
+			sb.WriteString(fmt.Sprintf("  Chip Error Rate (before despreading): %.2f%%\n", results.ChipErrorRate*100))
+		}
 	} else {
 		sb.WriteString("  BER: Not calculated / Relevant modules disabled\n")
 	}
+	if results.Alignment != nil {
+		sb.WriteString(fmt.Sprintf("  Edit Distance (aligned): %d = %d substitutions + %d insertions + %d deletions (%.2f%% of the original bits)\n",
+			results.Alignment.Distance, results.Alignment.Substitutions, results.Alignment.Insertions, results.Alignment.Deletions,
+			100*float64(results.Alignment.Distance)/float64(results.Original.Len())))
+	} else if results.Decoded != nil && results.SyncImpairment.Active() {
+		sb.WriteString("  Edit Distance: Not calculated / Sequences too far apart to align\n")
+	}
+	if results.Decoded != nil && results.PreambleLength > 0 {
+		sb.WriteString(fmt.Sprintf("  Estimated Code Phase: %d chips (from a %d bit preamble)\n", results.EstimatedCodePhase, results.PreambleLength))
+	}
+	if len(results.Correlations) > 0 {
+		sb.WriteString(fmt.Sprintf("  Mean Decision Margin |rho|: %.4f\n", simulation.MeanAbsCorrelation(results.Correlations)))
+	}
 	sb.WriteString("\nAutocorrelation (Max Absolute Off-Peak):\n")
 	if results.OriginalAutocorr != 0 || results.EncodedAutocorr != 0 || results.CorruptedAutocorr != 0 || (results.Original != nil) {
+		sb.WriteString(fmt.Sprintf("  Spreading Code: %.4f\n", results.CodeAutocorr))
 		sb.WriteString(fmt.Sprintf("  Original: %.4f\n", results.OriginalAutocorr))
 		sb.WriteString(fmt.Sprintf("  Encoded: %.4f\n", results.EncodedAutocorr))
 		sb.WriteString(fmt.Sprintf("  Corrupted: %.4f\n", results.CorruptedAutocorr))
 	} else {
 		sb.WriteString("  Autocorrelation analysis not performed or results are zero.\n")
 	}
+	sb.WriteString("\nLinear Complexity (Berlekamp-Massey):\n")
+	if results.OriginalComplexity != nil {
+		writeComplexity := func(label string, lc *simulation.LinearComplexity) {
+			if lc == nil {
+				return
+			}
+			sb.WriteString(fmt.Sprintf("  %s: L = %d / N = %d (L/N = %.4f), C(x) = %s\n", label, lc.Complexity, lc.Length, lc.Ratio(), lc.ConnectionString()))
+		}
+		writeComplexity("Spreading Code", results.CodeComplexity)
+		writeComplexity("Original", results.OriginalComplexity)
+		writeComplexity("Encoded", results.EncodedComplexity)
+		writeComplexity("Corrupted", results.CorruptedComplexity)
+	} else {
+		sb.WriteString("  Linear complexity analysis not performed.\n")
+	}
+	sb.WriteString(fmt.Sprintf("\nRandomness Tests (NIST SP 800-22, alpha = %.2f):\n", simulation.RandomnessAlpha))
+	if results.OriginalRandomness != nil {
+		writeRandomness := func(label string, tests []simulation.RandomnessTestResult) {
+			if tests == nil {
+				return
+			}
+			passed, applicable := simulation.CountPassed(tests)
+			sb.WriteString(fmt.Sprintf("  %s: %d / %d passed\n", label, passed, applicable))
+			for _, test := range tests {
+				switch {
+				case !test.Applicable:
+					sb.WriteString(fmt.Sprintf("    %s: not applicable (%s)\n", test.Name, test.Parameters))
+				case test.Passed:
+					sb.WriteString(fmt.Sprintf("    %s: p = %.6f PASS\n", test.Name, test.PValue))
+				default:
+					sb.WriteString(fmt.Sprintf("    %s: p = %.6f FAIL\n", test.Name, test.PValue))
+				}
+			}
+		}
+		writeRandomness("Spreading Code", results.CodeRandomness)
+		writeRandomness("Original", results.OriginalRandomness)
+		writeRandomness("Encoded", results.EncodedRandomness)
+	} else {
+		sb.WriteString("  Randomness tests not performed.\n")
+	}
 	sb.WriteString("\n==================================================\nEnd of Report\n")
 	return sb.String()
 }
@@ -139,55 +224,67 @@ func FormatCDMAResultsToText(results *simulation.CDMAResult) string {
 	sb.WriteString("Input Parameters:\n")
 	sb.WriteString(fmt.Sprintf("  Gold Code N: %d\n", results.N))
 	sb.WriteString(fmt.Sprintf("  Poly1 Taps: %v, Poly2 Taps: %v\n", results.Poly1, results.Poly2))
-	sb.WriteString(fmt.Sprintf("  User A Seeds (L1/L2): 0x%X / 0x%X\n", results.SeedA1, results.SeedA2))
-	sb.WriteString(fmt.Sprintf("  User B Seeds (L1/L2): 0x%X / 0x%X\n", results.SeedB1, results.SeedB2))
+	sb.WriteString(fmt.Sprintf("  Code Family: %s\n", results.Family))
+	sb.WriteString(fmt.Sprintf("  Number of Users: %d\n", len(results.Users)))
+	allRandom := true
+	for _, user := range results.Users {
+		if user.UseCodeIndex {
+			sb.WriteString(fmt.Sprintf("  User %s Code: #%d (%s), Power: %.2f, Input Text: \"%s\"\n", user.Label, user.CodeIndex, user.CodeName, user.Power, user.InputText))
+		} else {
+			sb.WriteString(fmt.Sprintf("  User %s Seeds (L1/L2): 0x%X / 0x%X, Power: %.2f, Input Text: \"%s\"\n", user.Label, user.Seed1, user.Seed2, user.Power, user.InputText))
+		}
+		if user.InputText != "" {
+			allRandom = false
+		}
+	}
 	sb.WriteString(fmt.Sprintf("  Noise Level: %.4f\n", results.NoiseLevel))
-	sb.WriteString(fmt.Sprintf("  Input Text A: \"%s\", Input Text B: \"%s\"\n", results.InputTextA, results.InputTextB))
-	if results.InputTextA == "" && results.InputTextB == "" {
+	sb.WriteString(fmt.Sprintf("  Master Seed: %d\n", results.Seed))
+	if allRandom {
 		sb.WriteString(fmt.Sprintf("  Random Seq Length: %d bits\n", results.SeqLengthForRandom))
 	}
 	sb.WriteString("\nData Lengths & Codes:\n")
-	sb.WriteString(fmt.Sprintf("  User A Data Bits: %d, User B Data Bits: %d\n", results.DataBitLengthUserA, results.DataBitLengthUserB))
 	sb.WriteString(fmt.Sprintf("  Gold Code Length: %d\n", results.GoldCodeLength))
-	sb.WriteString(fmt.Sprintf("  User A Gold Code: %s\n", results.GoldCodeAStr))
-	sb.WriteString(fmt.Sprintf("  User B Gold Code: %s\n", results.GoldCodeBStr))
-	sb.WriteString("\nCode Properties:\n")
-	sb.WriteString(fmt.Sprintf("  Autocorr Peak: %d, Max Off-Peak A: %.4f, Max Off-Peak B: %.4f\n", results.AutocorrelationPeak, results.MaxOffPeakAutocorrelationA, results.MaxOffPeakAutocorrelationB))
-	sb.WriteString(fmt.Sprintf("  Cross-Correlation (A vs B): %.4f\n", results.CrossCorrelationAB))
-	sb.WriteString("\nUser A Path:\n")
-	if results.OriginalDataSeqA != nil {
-		sb.WriteString(fmt.Sprintf("  Original A: %s\n", results.OriginalDataSeqA.String()))
+	for _, user := range results.Users {
+		sb.WriteString(fmt.Sprintf("  User %s Data Bits: %d, Gold Code: %s\n", user.Label, user.DataBitLength, user.GoldCodeStr))
+		sb.WriteString(fmt.Sprintf("  User %s Spreading Factor: %d, Delay: %d chips\n", user.Label, user.SpreadingFactor, user.Delay))
 	}
-	if results.EncodedDataSeqA != nil {
-		sb.WriteString(fmt.Sprintf("  Encoded A: %s\n", results.EncodedDataSeqA.String()))
+	sb.WriteString("\nCode Properties:\n")
+	sb.WriteString(fmt.Sprintf("  Autocorr Peak: %d\n", results.AutocorrelationPeak))
+	for _, user := range results.Users {
+		sb.WriteString(fmt.Sprintf("  Max Off-Peak %s: %.4f\n", user.Label, user.MaxOffPeakAutocorrelation))
 	}
-	sb.WriteString(fmt.Sprintf("  Transmitted A (trunc): %s\n", results.TransmittedSignalAStr))
-	sb.WriteString("\nUser B Path:\n")
-	if results.OriginalDataSeqB != nil {
-		sb.WriteString(fmt.Sprintf("  Original B: %s\n", results.OriginalDataSeqB.String()))
+	for i := range results.Users {
+		for j := i + 1; j < len(results.Users); j++ {
+			sb.WriteString(fmt.Sprintf("  Cross-Correlation (%s vs %s): %.4f\n", results.Users[i].Label, results.Users[j].Label, results.CrossCorrelation[i][j]))
+		}
 	}
-	if results.EncodedDataSeqB != nil {
-		sb.WriteString(fmt.Sprintf("  Encoded B: %s\n", results.EncodedDataSeqB.String()))
+	sb.WriteString(fmt.Sprintf("  Max Cross-Correlation: %.4f\n", results.MaxCrossCorrelation))
+	for _, user := range results.Users {
+		sb.WriteString(fmt.Sprintf("\nUser %s Path:\n", user.Label))
+		if user.OriginalDataSeq != nil {
+			sb.WriteString(fmt.Sprintf("  Original %s: %s\n", user.Label, user.OriginalDataSeq.String()))
+		}
+		if user.EncodedDataSeq != nil {
+			sb.WriteString(fmt.Sprintf("  Encoded %s: %s\n", user.Label, user.EncodedDataSeq.String()))
+		}
+		sb.WriteString(fmt.Sprintf("  Transmitted %s (trunc): %s\n", user.Label, user.TransmittedSignalStr))
 	}
-	sb.WriteString(fmt.Sprintf("  Transmitted B (trunc): %s\n", results.TransmittedSignalBStr))
 	sb.WriteString("\nChannel & Reception:\n")
 	sb.WriteString(fmt.Sprintf("  Combined (trunc): %s\n", results.CombinedSignalStr))
 	sb.WriteString(fmt.Sprintf("  Received (trunc): %s\n", results.ReceivedSignalStr))
-	sb.WriteString(fmt.Sprintf("  Rx Segment A (trunc): %s, Rx Segment B (trunc): %s\n", results.ReceivedSignalSegmentAStr, results.ReceivedSignalSegmentBStr))
-	sb.WriteString("\nUser A Decoding:\n")
-	sb.WriteString(fmt.Sprintf("  Correlated A (trunc): %s\n", results.CorrelatedSignalUserAStr))
-	if results.DecodedDataSeqA != nil {
-		sb.WriteString(fmt.Sprintf("  Decoded A: %s\n", results.DecodedDataSeqA.String()))
-		sb.WriteString(fmt.Sprintf("  Decoded Text A: \"%s\"\n", results.DecodedTextA))
-	}
-	sb.WriteString(fmt.Sprintf("  BER A: %.2f%%, Errors A: %d/%d\n", results.BER_A*100, results.ErrorCountA, results.DataBitLengthUserA))
-	sb.WriteString("\nUser B Decoding:\n")
-	sb.WriteString(fmt.Sprintf("  Correlated B (trunc): %s\n", results.CorrelatedSignalUserBStr))
-	if results.DecodedDataSeqB != nil {
-		sb.WriteString(fmt.Sprintf("  Decoded B: %s\n", results.DecodedDataSeqB.String()))
-		sb.WriteString(fmt.Sprintf("  Decoded Text B: \"%s\"\n", results.DecodedTextB))
-	}
-	sb.WriteString(fmt.Sprintf("  BER B: %.2f%%, Errors B: %d/%d\n", results.BER_B*100, results.ErrorCountB, results.DataBitLengthUserB))
+	for _, user := range results.Users {
+		sb.WriteString(fmt.Sprintf("  Rx Segment %s (trunc): %s\n", user.Label, user.ReceivedSignalSegmentStr))
+	}
+	for _, user := range results.Users {
+		sb.WriteString(fmt.Sprintf("\nUser %s Decoding:\n", user.Label))
+		sb.WriteString(fmt.Sprintf("  Correlated %s (trunc): %s\n", user.Label, user.CorrelatedSignalStr))
+		if user.DecodedDataSeq != nil {
+			sb.WriteString(fmt.Sprintf("  Decoded %s: %s\n", user.Label, user.DecodedDataSeq.String()))
+			sb.WriteString(fmt.Sprintf("  Decoded Text %s: \"%s\"\n", user.Label, user.DecodedText))
+		}
+		sb.WriteString(fmt.Sprintf("  MAI %s: %.4f\n", user.Label, user.MAI))
+		sb.WriteString(fmt.Sprintf("  BER %s: %.2f%%, Errors %s: %d/%d\n", user.Label, user.BER*100, user.Label, user.ErrorCount, user.DataBitLength))
+	}
 	sb.WriteString("\n======================================================\nEnd of CDMA Report\n")
 	return sb.String()
 }
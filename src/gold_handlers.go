@@ -0,0 +1,371 @@
+package src
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/BartiX259/BSO_Projekt/src/simulation"
+)
+
+// Limits of a single preferred pair search
+const (
+	preferredPairsDefaultLimit = 20
+	preferredPairsMaxLimit     = 200
+)
+
+// PreferredPairsResponse is the JSON body of GET /api/v1/gold/preferred-pairs
+type PreferredPairsResponse struct {
+	N      uint                    `json:"n"`
+	Bound  int                     `json:"bound"`  // t(n)
+	Values []int                   `json:"values"` // Cross-correlation values of a preferred pair
+	Pairs  []PreferredPairResponse `json:"pairs"`
+}
+
+// PreferredPairResponse is a single preferred pair with the LFSR taps generating it
+type PreferredPairResponse struct {
+	Poly1 string `json:"poly1"`
+	Poly2 string `json:"poly2"`
+	Taps1 []uint `json:"taps1"`
+	Taps2 []uint `json:"taps2"`
+}
+
+// PreferredPairsData holds data for the preferred pair picker template
+type PreferredPairsData struct {
+	N       uint
+	Values  []int
+	Partner string // Polynomial of LFSR1 the partners were searched for, empty when listing any pairs
+	Pairs   []PreferredPairRow
+	Message string
+}
+
+// PreferredPairRow is one pair offered by the picker
+type PreferredPairRow struct {
+	Poly1    string
+	Poly2    string
+	Taps1Str string
+	Taps2Str string
+}
+
+// searchPreferredPairs finds preferred pairs of degree n. If taps1 gives a primitive polynomial,
+// only its partners are returned. The returned partner polynomial is 0 otherwise.
+func searchPreferredPairs(n uint, taps1 []uint, limit int) ([]simulation.PreferredPair, simulation.Polynomial) {
+	var first simulation.Polynomial
+	if len(taps1) > 0 {
+		if p := simulation.TapsToPolynomial(n, taps1); p.IsPrimitive() {
+			first = p
+		}
+	}
+	return simulation.FindPreferredPairs(n, first, limit), first
+}
+
+// CDMAPreferredPairsHandler renders the preferred pair picker of the CDMA system config module
+func CDMAPreferredPairsHandler(w http.ResponseWriter, r *http.Request) {
+	n := uint(parseIntWithDefault(r.FormValue("cdmaGoldN"), 4, 2, 16))
+	data := PreferredPairsData{N: n, Values: simulation.PreferredPairValues(n)}
+
+	switch {
+	case n%4 == 0:
+		data.Message = fmt.Sprintf("Dla n = %d (podzielnego przez 4) pary preferowane nie istnieją.", n)
+	case n > simulation.MaxPreferredPairSearchDegree:
+		data.Message = fmt.Sprintf("Wyszukiwanie par preferowanych jest dostępne dla n ≤ %d.", simulation.MaxPreferredPairSearchDegree)
+	default:
+		taps1, _ := parseTaps(r.FormValue("cdmaGoldTaps1")) // Negative taps are ignored like an empty list
+		pairs, partner := searchPreferredPairs(n, taps1, preferredPairsDefaultLimit)
+		if partner != 0 {
+			data.Partner = partner.String()
+		}
+		for _, pair := range pairs {
+			data.Pairs = append(data.Pairs, PreferredPairRow{
+				Poly1:    pair.Poly1.String(),
+				Poly2:    pair.Poly2.String(),
+				Taps1Str: joinTaps(pair.Taps1),
+				Taps2Str: joinTaps(pair.Taps2),
+			})
+		}
+		if len(data.Pairs) == 0 {
+			data.Message = "Nie znaleziono par preferowanych."
+		}
+	}
+
+	tmpl, err := template.ParseFiles("templates/cdma_preferred_pairs.html")
+	if err != nil {
+		log.Printf("CDMAPreferredPairsHandler: Template error: %v", err)
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("Error executing CDMAPreferredPairsHandler template: %v", err)
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	}
+}
+
+// APIPreferredPairsHandler returns preferred pairs of degree ?n= as JSON. With ?taps1= only
+// the partners of that register are listed, ?limit= caps the number of pairs.
+func APIPreferredPairsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, APIError{Error: "method not allowed, use GET"})
+		return
+	}
+	query := r.URL.Query()
+
+	var errs fieldErrors
+	n, err := strconv.Atoi(query.Get("n"))
+	if err != nil || n < 2 || n > simulation.MaxPreferredPairSearchDegree {
+		errs.add("n", "must be between 2 and %d", simulation.MaxPreferredPairSearchDegree)
+	}
+	limit := preferredPairsDefaultLimit
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > preferredPairsMaxLimit {
+			errs.add("limit", "must be between 1 and %d", preferredPairsMaxLimit)
+		}
+	}
+	taps1 := parseQueryTaps(&errs, "taps1", query.Get("taps1"), n)
+	if len(errs) == 0 && taps1 != nil && !simulation.TapsToPolynomial(uint(n), taps1).IsPrimitive() {
+		errs.add("taps1", "taps do not give a primitive polynomial")
+	}
+	if len(errs) > 0 {
+		writeAPIValidationError(w, errs)
+		return
+	}
+
+	pairs, _ := searchPreferredPairs(uint(n), taps1, limit)
+	response := PreferredPairsResponse{
+		N:      uint(n),
+		Bound:  simulation.PreferredPairBound(uint(n)),
+		Values: simulation.PreferredPairValues(uint(n)),
+		Pairs:  []PreferredPairResponse{},
+	}
+	for _, pair := range pairs {
+		response.Pairs = append(response.Pairs, PreferredPairResponse{
+			Poly1: pair.Poly1.String(),
+			Poly2: pair.Poly2.String(),
+			Taps1: pair.Taps1,
+			Taps2: pair.Taps2,
+		})
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// Paging of the gold family listing
+const (
+	goldFamilyPageSize = 16
+	goldFamilyMaxLimit = 64
+)
+
+// GoldFamilyResponse is the JSON body of GET /api/v1/gold/family
+type GoldFamilyResponse struct {
+	N         uint               `json:"n"`
+	Length    int                `json:"length"` // Code length 2^n - 1
+	Size      int                `json:"size"`   // Number of codes 2^n + 1
+	Poly1     string             `json:"poly1"`
+	Poly2     string             `json:"poly2"`
+	Preferred bool               `json:"preferred"` // The registers form a preferred pair
+	Offset    int                `json:"offset"`
+	Codes     []GoldCodeResponse `json:"codes"`
+}
+
+// GoldCodeResponse describes one code of the family
+type GoldCodeResponse struct {
+	Index                     int     `json:"index"`
+	Name                      string  `json:"name"`
+	Ones                      int     `json:"ones"`
+	Zeros                     int     `json:"zeros"`
+	Balanced                  bool    `json:"balanced"`
+	MaxOffPeakAutocorrelation float32 `json:"maxOffPeakAutocorrelation"`
+	Code                      string  `json:"code,omitempty"` // Only with ?codes=true
+}
+
+// GoldFamilyData holds data for the gold family table template
+type GoldFamilyData struct {
+	N          uint
+	Length     int
+	Size       int
+	Poly1      string
+	Poly2      string
+	Preferred  bool
+	Codes      []GoldCodeRow
+	Offset     int
+	PrevOffset int
+	NextOffset int // -1 on the last page
+}
+
+// GoldCodeRow is one code of the gold family table
+type GoldCodeRow struct {
+	simulation.GoldCodeStats
+	CodePrefix string
+}
+
+// goldFamilyPage computes the statistics of up to limit codes of the family starting at offset
+func goldFamilyPage(family *simulation.GoldFamily, offset, limit int) []simulation.GoldCodeStats {
+	end := min(offset+limit, family.Size())
+	stats := make([]simulation.GoldCodeStats, 0, max(end-offset, 0))
+	for index := offset; index < end; index++ {
+		stats = append(stats, family.Stats(index))
+	}
+	return stats
+}
+
+// goldPairPreferred reports whether the taps of the family registers form a preferred pair
+func goldPairPreferred(n uint, taps1, taps2 []uint) bool {
+	preferred, _ := simulation.IsPreferredPair(simulation.TapsToPolynomial(n, taps1), simulation.TapsToPolynomial(n, taps2))
+	return preferred
+}
+
+// CDMAGoldFamilyHandler renders one page (?offset=) of the gold family of the CDMA system config module
+func CDMAGoldFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	n, taps1, taps2, err := parseCDMACodeConfig(CDMAFormData{
+		GoldNStr:     r.FormValue("cdmaGoldN"),
+		GoldTaps1Str: r.FormValue("cdmaGoldTaps1"),
+		GoldTaps2Str: r.FormValue("cdmaGoldTaps2"),
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if message := formTapsError(simulation.CodeFamilyGold, n, taps1, taps2); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+
+	family := simulation.NewGoldFamily(n, taps1, taps2)
+	offset := parseIntWithDefault(r.FormValue("offset"), 0, 0, family.Size()-1)
+	offset -= offset % goldFamilyPageSize
+	data := GoldFamilyData{
+		N:          n,
+		Length:     family.Length,
+		Size:       family.Size(),
+		Poly1:      simulation.TapsToPolynomial(n, taps1).String(),
+		Poly2:      simulation.TapsToPolynomial(n, taps2).String(),
+		Preferred:  goldPairPreferred(n, taps1, taps2),
+		Offset:     offset,
+		PrevOffset: max(offset-goldFamilyPageSize, 0),
+		NextOffset: offset + goldFamilyPageSize,
+	}
+	if data.NextOffset >= family.Size() {
+		data.NextOffset = -1
+	}
+	for _, stats := range goldFamilyPage(family, offset, goldFamilyPageSize) {
+		code := family.Code(stats.Index).String()
+		if len(code) > 32 {
+			code = code[:32] + "..."
+		}
+		data.Codes = append(data.Codes, GoldCodeRow{GoldCodeStats: stats, CodePrefix: code})
+	}
+
+	tmpl, err := template.ParseFiles("templates/cdma_gold_family.html")
+	if err != nil {
+		log.Printf("CDMAGoldFamilyHandler: Template error: %v", err)
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("Error executing CDMAGoldFamilyHandler template: %v", err)
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	}
+}
+
+// APIGoldFamilyHandler returns the statistics of the gold family of ?n=, ?taps1= and ?taps2= as JSON.
+// ?offset= and ?limit= select a page of codes, ?codes=true also includes the code bits.
+func APIGoldFamilyHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSON(w, http.StatusMethodNotAllowed, APIError{Error: "method not allowed, use GET"})
+		return
+	}
+	query := r.URL.Query()
+
+	var errs fieldErrors
+	n, err := strconv.Atoi(query.Get("n"))
+	if err != nil || n < 2 || n > 16 {
+		errs.add("n", "must be between 2 and 16")
+	}
+	taps1 := parseQueryTaps(&errs, "taps1", query.Get("taps1"), n)
+	taps2 := parseQueryTaps(&errs, "taps2", query.Get("taps2"), n)
+	if strings.TrimSpace(query.Get("taps1")) == "" {
+		errs.add("taps1", "at least one tap is required")
+	}
+	if strings.TrimSpace(query.Get("taps2")) == "" {
+		errs.add("taps2", "at least one tap is required")
+	}
+	size := 1<<max(n, 0) + 1
+	offset := 0
+	if offsetStr := query.Get("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 || offset >= size {
+			errs.add("offset", "must be between 0 and %d", size-1)
+		}
+	}
+	limit := goldFamilyPageSize
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil || limit < 1 || limit > goldFamilyMaxLimit {
+			errs.add("limit", "must be between 1 and %d", goldFamilyMaxLimit)
+		}
+	}
+	withCodes := query.Get("codes") == "true"
+	if len(errs) > 0 {
+		writeAPIValidationError(w, errs)
+		return
+	}
+
+	family := simulation.NewGoldFamily(uint(n), taps1, taps2)
+	response := GoldFamilyResponse{
+		N:         uint(n),
+		Length:    family.Length,
+		Size:      family.Size(),
+		Poly1:     simulation.TapsToPolynomial(uint(n), taps1).String(),
+		Poly2:     simulation.TapsToPolynomial(uint(n), taps2).String(),
+		Preferred: goldPairPreferred(uint(n), taps1, taps2),
+		Offset:    offset,
+		Codes:     []GoldCodeResponse{},
+	}
+	for _, stats := range goldFamilyPage(family, offset, limit) {
+		code := GoldCodeResponse{
+			Index:                     stats.Index,
+			Name:                      stats.Name,
+			Ones:                      stats.Ones,
+			Zeros:                     stats.Zeros,
+			Balanced:                  stats.Balanced,
+			MaxOffPeakAutocorrelation: stats.MaxOffPeakAutocorrelation,
+		}
+		if withCodes {
+			code.Code = family.Code(stats.Index).String()
+		}
+		response.Codes = append(response.Codes, code)
+	}
+	writeJSON(w, http.StatusOK, response)
+}
+
+// parseQueryTaps parses a comma separated tap list of a query parameter, nil when it is empty.
+// Taps that do not fit in an n-bit register are reported as a field error.
+func parseQueryTaps(errs *fieldErrors, field, value string, n int) []uint {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil
+	}
+	var taps []uint
+	for _, part := range strings.Split(value, ",") {
+		tap, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || tap < 0 || tap >= n {
+			errs.add(field, "must be a comma separated list of taps below n")
+			return nil
+		}
+		taps = append(taps, uint(tap))
+	}
+	return taps
+}
+
+// joinTaps formats taps the way the form fields expect them, e.g. "0,3"
+func joinTaps(taps []uint) string {
+	parts := make([]string, len(taps))
+	for i, t := range taps {
+		parts[i] = strconv.FormatUint(uint64(t), 10)
+	}
+	return strings.Join(parts, ",")
+}
@@ -5,13 +5,16 @@ import (
 	"html/template"
 	"log"
 	"math"
+	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/BartiX259/BSO_Projekt/src/simulation"
 )
@@ -27,81 +30,127 @@ type SimulationResults struct {
 	InputText         string
 	ErrorType         string
 	ErrorRate         float64
+	ErrorModel        simulation.ErrorModel // All parameters of the error model, probabilities as fractions
 	ErrorsIntroduced  int
+	ErrorEnabled      bool // Error module was enabled, otherwise Corrupted holds the encoded bits
 	Timestamp         string
 	GoldN             int
 	GoldTaps1         []uint
 	GoldTaps2         []uint
+	CodeFamily        string
+	CodeName          string
 	DecoderType       string
+	SpreadingMode     string  // simulation.SpreadingModeScramble or simulation.SpreadingModeDSSS
+	SpreadingFactor   int     // Chips per data bit, 1 when scrambling
+	ChipErrorRate     float32 // Errors introduced per transmitted chip
+	CodeAutocorr      float32 // Spreading code
 	OriginalAutocorr  float32
 	EncodedAutocorr   float32
 	CorruptedAutocorr float32
-	mutex             sync.RWMutex
+	// Decoder synchronization: the chips the transmitter code leads the receiver code by, the phase
+	// the decoder synchronized to (-1 for decoders that assume phase 0) and the known preamble bits
+	CodePhaseOffset    int
+	EstimatedCodePhase int
+	PreambleLength     int
+	// Normalized correlation of every decoded bit, nil for decoders without soft output
+	Correlations []float64
+	// Positions of the erasure channel: erased chips of Corrupted and undecided bits of Decoded,
+	// nil when there are none, with their counts. Erased bits are not counted in ErrorCount and BER.
+	CorruptedErased    *simulation.BitSequence
+	ErasuresIntroduced int
+	DecodedErased      *simulation.BitSequence
+	ErasureCount       int
+	// Synchronization errors applied after the error model, zero when none, and the minimal edit
+	// alignment of the decoded bits with the original, nil when not computed
+	SyncImpairment simulation.SyncImpairment
+	SyncStats      simulation.SyncStats
+	Alignment      *simulation.Alignment
+	// Linear complexity of every sequence, nil when the module is disabled
+	CodeComplexity      *simulation.LinearComplexity
+	OriginalComplexity  *simulation.LinearComplexity
+	EncodedComplexity   *simulation.LinearComplexity
+	CorruptedComplexity *simulation.LinearComplexity
+	// NIST SP 800-22 tests of the code, the input and the encoded sequence, nil when the module is disabled
+	CodeRandomness     []simulation.RandomnessTestResult
+	OriginalRandomness []simulation.RandomnessTestResult
+	EncodedRandomness  []simulation.RandomnessTestResult
+	Seed               int64
+	mutex              sync.RWMutex
 }
 
-var globalResults = &SimulationResults{}
-
-var latestGeneralSimFilePath string
-var latestGeneralSimFileMutex sync.RWMutex
-
-var latestCDMASimFilePath string
-var latestCDMASimFileMutex sync.RWMutex
-
 type CDMASimulationState struct {
 	mutex sync.RWMutex
 
 	GlobalN     uint
 	GlobalPoly1 []uint
 	GlobalPoly2 []uint
+	Family      string
 	Timestamp   string
+	Seed        int64
 
-	InputTextA         string
-	SeedA1_form        uint64
-	SeedA2_form        uint64
-	OriginalDataStrA   string
-	EncodedDataStrA    string
-	DataLengthA        int
-	GeneratedGoldCodeA string
-
-	InputTextB         string
-	SeedB1_form        uint64
-	SeedB2_form        uint64
-	OriginalDataStrB   string
-	EncodedDataStrB    string
-	DataLengthB        int
-	GeneratedGoldCodeB string
+	Users []CDMAUserState
 
 	SimulationDataLength        int
 	FullTransmittedSignalLength int
 
-	NoiseLevel_form           float64
-	TransmittedSignalAStr     string
-	TransmittedSignalBStr     string
-	CombinedSignalStr         string
-	ReceivedSignalStr         string
-	GoldCodeLength            int
-	ReceivedSignalSegmentAStr string
-	ReceivedSignalSegmentBStr string
-	CorrelatedSignalAStr      string
-	CorrelatedSignalBStr      string
+	NoiseLevel_form   float64
+	CombinedSignalStr string
+	ReceivedSignalStr string
+	GoldCodeLength    int
 
-	DecodedTextA    string
-	DecodedDataStrA string
-	ErrorCountA     int
-	BER_A_str       string
+	AutocorrelationPeak int
+	CrossCorrelation    [][]float32
+	MaxCrossCorrelation float32
 
-	DecodedTextB    string
-	DecodedDataStrB string
-	ErrorCountB     int
-	BER_B_str       string
+	TapsWarnings []string // Non-primitive taps, not a preferred pair or too few codes
+}
 
-	AutocorrelationPeak        int
-	MaxOffPeakAutocorrelationA float32
-	MaxOffPeakAutocorrelationB float32
-	CrossCorrelationAB         float32
+// CDMAUserState holds the display data of a single CDMA user
+type CDMAUserState struct {
+	Label             string
+	InputText         string
+	Seed1_form        uint64
+	Seed2_form        uint64
+	UseCodeIndex      bool
+	CodeIndex         int
+	CodeName          string
+	SpreadingFactor   int
+	Delay             int
+	Power             float64
+	OriginalDataStr   string
+	EncodedDataStr    string
+	DataLength        int
+	GeneratedGoldCode string
+	Code              *simulation.BitSequence
+
+	TransmittedSignalStr     string
+	ReceivedSignalSegmentStr string
+	CorrelatedSignalStr      string
+
+	DecodedText    string
+	DecodedDataStr string
+	ErrorCount     int
+	BER_str        string
+
+	MaxOffPeakAutocorrelation float32
+	MAI                       float32
 }
 
-var cdmaGlobalState = &CDMASimulationState{}
+// Limits on the CDMA form input, keeping a single request reasonably fast
+const (
+	cdmaMaxUsers        = 16      // Simultaneous users accepted from the form
+	cdmaMaxTextLength   = 50      // Characters of the text one user sends, as the maxlength of the form field
+	cdmaSweepMaxPoints  = 41      // Noise points of one BER sweep
+	cdmaSweepMaxBits    = 1000000 // Bits per user and noise point of one BER sweep
+	cdmaSweepMaxWorkers = 64      // Goroutines running the trials of one BER sweep
+	cdmaSweepMaxChips   = 1 << 33 // Chips of all users and noise points of one BER sweep
+)
+
+// CDMASweepState holds the latest Monte Carlo BER sweep
+type CDMASweepState struct {
+	mutex  sync.RWMutex
+	Result *simulation.SweepResult
+}
 
 // ResponseData holds data for the response template
 type ResponseData struct {
@@ -117,6 +166,7 @@ type GeneratorData struct {
 	BitSequence string
 	InputText   string
 	Length      int
+	Seed        int64
 }
 
 // EncoderData holds data for encoder template
@@ -125,23 +175,49 @@ type EncoderData struct {
 	EncodedSequence string
 	N               int
 	Length          int
+	FamilyLabel     string
+	CodeName        string
+	UsesLFSR1       bool
+	UsesLFSR2       bool
 	Taps1           []uint
 	Taps2           []uint
+	DSSS            bool
+	SpreadingFactor int
+	ProcessingGain  string // dB
+	PreambleLength  int    // Known bits sent before the data
 }
 
 // ErrorData holds data for error template
 type ErrorData struct {
-	CorruptedSequence string
-	ErrorType         string
-	ErrorRate         float64
-	ErrorsIntroduced  int
+	CorruptedSequence  string
+	ErrorType          string
+	ErrorRate          float64
+	ErrorsIntroduced   int
+	ChipLevel          bool   // Errors hit the chips of the DSSS mode
+	ModelDescription   string // Parameters of the error model
+	Erasure            bool   // Erasure channel, CorruptedSequence marks erased positions with '?'
+	ErasuresIntroduced int
+	// Synchronization errors, SyncDescription is empty when none were applied
+	SyncDescription string
+	Insertions      int
+	Deletions       int
+	Slips           int
+	EncodedLength   int
 }
 
 // DecoderData holds data for decoder template
 type DecoderData struct {
-	DecodedSequence string
-	DecoderType     string
-	DecodedASCII    string
+	DecodedSequence    string
+	DecoderType        string
+	DecoderLabel       string
+	DecodedASCII       string
+	CodePhaseOffset    int
+	EstimatedCodePhase int // -1 when the decoder assumes phase 0
+	PreambleLength     int
+	ErasedBits         int        // Bits left undecided, marked '?' in DecodedSequence
+	MeanMargin         string     // Mean absolute bit correlation, empty without soft output
+	MinMargin          string     // Weakest bit correlation
+	Chart              *ChartData // Bit correlations, nil without soft output
 }
 
 // BERData holds data for BER template
@@ -153,74 +229,155 @@ type BERData struct {
 	DecodedSequence  string
 	OriginalASCII    string
 	DecodedASCII     string
+	// DSSS mode only: the error rate of the chips against the BER after despreading
+	DSSS            bool
+	ChipErrorRate   string
+	SpreadingFactor int
+	ProcessingGain  string // dB
+	// Erasure channel only: undecided bits, left out of ErrorsDetected and BER
+	Erasure     bool
+	Erasures    int
+	ErasureRate string
+	// Synchronization errors only: the edit alignment of the decoded bits with the original
+	DecodedBits   int
+	Sync          bool
+	Aligned       bool // False when the sequences were too far apart to align
+	EditDistance  int
+	Substitutions int
+	Insertions    int
+	Deletions     int
+	AlignedBER    string
 }
 
 // AutocorrelationData holds data for autocorrelation template
 type AutocorrelationData struct {
+	CodeMaxOffPeak      string
 	OriginalMaxOffPeak  string
 	EncodedMaxOffPeak   string
 	CorruptedMaxOffPeak string
 }
 
+// LinearComplexityData holds data for the linear complexity template
+type LinearComplexityData struct {
+	Rows  []LinearComplexityRow
+	Chart *ChartData
+}
+
+// LinearComplexityRow is the Berlekamp-Massey result of a single sequence
+type LinearComplexityRow struct {
+	Label      string
+	Length     int
+	Complexity int
+	Ratio      string
+	Connection string
+	Taps       string // Only for short registers
+}
+
+// RandomnessData holds data for the randomness tests template
+type RandomnessData struct {
+	Sequences []string // Column headers
+	Rows      []RandomnessRow
+	Passed    []string // "passed / applicable" of every column
+	Alpha     float64
+}
+
+// RandomnessRow is a single test run on every sequence
+type RandomnessRow struct {
+	Label   string
+	Name    string // NIST name
+	Results []RandomnessCell
+}
+
+// RandomnessCell is the result of a test on a single sequence
+type RandomnessCell struct {
+	PValue     string
+	Passed     bool
+	Applicable bool
+	Parameters string
+}
+
 // --- NEW: CDMA Handler Data Structs ---
 type CDMAFormData struct { // Matches form fields
 	GoldNStr     string // Mod 1
 	GoldTaps1Str string // Mod 1
 	GoldTaps2Str string // Mod 1
+	FamilyStr    string // Mod 1
 
-	TextUserAStr string // Mod 2A
-	SeedA1Str    string // Mod 2A
-	SeedA2Str    string // Mod 2A
+	Users []CDMAUserFormData // Mod 2
 
-	TextUserBStr string // Mod 2B
-	SeedB1Str    string // Mod 2B
-	SeedB2Str    string // Mod 2B
-
-	SeqLengthRandomStr string // Fallback if texts are empty (common for A & B if both random)
+	SeqLengthRandomStr string // Fallback for users with empty text
 
 	NoiseLevelStr string // Mod 3
 }
 
+// CDMAUserFormData holds the form fields of a single transmitter
+type CDMAUserFormData struct {
+	TextStr      string
+	Seed1Str     string
+	Seed2Str     string
+	CodeIndexStr string // Code set index, the seeds (or the user's position for Kasami sets) are used when empty
+	SFStr        string // OVSF spreading factor, the longest when empty
+	DelayStr     string // Chip delay at the receiver
+	PowerStr     string
+}
+
 // Data structs for individual CDMA result templates (Module specific)
 type CDMASystemConfigData struct { // For Module 1 results display
-	Timestamp                  string
-	GlobalN                    uint
-	GlobalPoly1                []uint
-	GlobalPoly2                []uint
-	GeneratedGoldCodeA         string // Display part of Gold Code A
-	GeneratedGoldCodeB         string // Display part of Gold Code B
-	GoldCodeLength             int
-	MaxOffPeakAutocorrelationA float32
-	MaxOffPeakAutocorrelationB float32
-	CrossCorrelationAB         float32
-}
-
-type CDMATransmitterUserData struct { // For Module 2 results (User A or B)
-	Timestamp       string
-	UserLabel       string // "A" or "B"
-	InputText       string
-	Seed1           uint64
-	Seed2           uint64
-	OriginalDataStr string
-	EncodedDataStr  string // NEW: Encoded data string
-	DataLength      int
-	GoldCodeLength  int // For context
-	// TransmittedSignalStr is already in the anonymous struct in the handler
-	FullTransmittedSignalLength int // NEW: Full length of the transmitted signal
+	Timestamp           string
+	Seed                int64
+	GlobalN             uint
+	GlobalPoly1         []uint
+	GlobalPoly2         []uint
+	FamilyLabel         string
+	UsesLFSR1           bool
+	UsesLFSR2           bool
+	Users               []CDMACodeData
+	GoldCodeLength      int
+	MaxCrossCorrelation float32
+	TapsWarnings        []string
+}
+
+// CDMACodeData describes the code assigned to one user
+type CDMACodeData struct {
+	UserLabel                 string
+	GeneratedGoldCode         string // Display part of the Gold Code
+	CodeName                  string
+	SpreadingFactor           int
+	Delay                     int
+	MaxOffPeakAutocorrelation float32
+	MAI                       float32 // Multiple access interference relative to the user's correlation peak
+}
+
+type CDMATransmitterUserData struct { // For Module 2 results (one per user)
+	UserLabel                   string
+	InputText                   string
+	Seed1                       uint64
+	Seed2                       uint64
+	UseCodeIndex                bool
+	CodeIndex                   int
+	CodeName                    string
+	SpreadingFactor             int
+	Delay                       int
+	Power                       float64
+	OriginalDataStr             string
+	EncodedDataStr              string
+	DataLength                  int
+	TransmittedSignalStr        string
+	FullTransmittedSignalLength int
 }
 
 type CDMAChannelData struct { // For Module 3 results
 	Timestamp         string
 	NoiseLevel        float64
+	NumUsers          int
 	CombinedSignalStr string
 	ReceivedSignalStr string
 	DataBitLength     int // SimulationDataLength
 	GoldCodeLength    int
 }
 
-type CDMAReceiverUserData struct { // For Module 4 results (User A or B)
-	Timestamp                string
-	UserLabel                string // "A" or "B"
+type CDMAReceiverUserData struct { // For Module 4 results (one per user)
+	UserLabel                string
 	InputText                string // Original text
 	DecodedText              string
 	OriginalDataStr          string // For comparison if needed
@@ -228,17 +385,80 @@ type CDMAReceiverUserData struct { // For Module 4 results (User A or B)
 	ErrorCount               int
 	BER_str                  string
 	DataLength               int
-	ReceivedSignalSegmentStr string // NEW: Received signal segment for this user
-	CorrelatedSignalStr      string // NEW: Correlated signal for this user
+	ReceivedSignalSegmentStr string // Received signal segment for this user
+	CorrelatedSignalStr      string // Correlated signal for this user
+}
+
+type CDMABERUserData struct { // For Module 5 results (one per user)
+	UserLabel   string
+	BER_str     string
+	ErrorCount  int
+	TotalBits   int
+	InputText   string
+	DecodedText string
+}
+
+type CDMACodeAnalysisData struct { // For Module 6 results
+	Timestamp           string
+	AutocorrelationPeak int
+	Users               []CDMACodeData
+	UserLabels          []string
+	CrossCorrelation    [][]float32
+	MaxCrossCorrelation float32
+	GoldCodeLength      int // For context (same as AutocorrelationPeak)
+
+	// Correlation functions of the selected codes A and B
+	CodeA          int
+	CodeB          int
+	PeriodicChart  *ChartData
+	AperiodicChart *ChartData
+	OddChart       *ChartData
+	AutoHistogram  []CorrelationHistogramRow // Off-peak periodic autocorrelation of A
+	CrossHistogram []CorrelationHistogramRow // Periodic cross-correlation of A and B
+
+	// Gold three-valued property, checked for the Gold family only
+	GoldCheck        bool
+	GoldValues       []int
+	AutoThreeValued  bool
+	CrossThreeValued bool
+}
+
+// CorrelationHistogramRow is one distinct correlation value and the number of shifts it occurs at
+type CorrelationHistogramRow struct {
+	Value      int
+	Normalized string
+	Count      int
+}
+
+// CDMASweepData holds data for the BER sweep template
+type CDMASweepData struct {
+	Timestamp    string
+	XLabel       string
+	UserLabels   []string
+	CodeLength   int
+	BitsPerTrial int
+	TargetErrors int
+	MaxBits      int
+	Workers      int
+	Seed         int64
+	Rows         []CDMASweepRow
+	Chart        *ChartData
+}
+
+// CDMASweepRow is one noise point of the BER sweep table
+type CDMASweepRow struct {
+	Value      string
+	EbN0dB     string
+	NoiseSigma string
+	Trials     int
+	Users      []CDMASweepCell
 }
 
-type CDMACodeAnalysisData struct { // For Module 5 results
-	Timestamp                  string
-	AutocorrelationPeak        int
-	MaxOffPeakAutocorrelationA float32
-	MaxOffPeakAutocorrelationB float32
-	CrossCorrelationAB         float32
-	GoldCodeLength             int // For context (same as AutocorrelationPeak)
+// CDMASweepCell is the BER of one user at one noise point
+type CDMASweepCell struct {
+	BER_str string
+	Errors  int
+	Bits    int
 }
 
 // --- END NEW ---
@@ -260,9 +480,7 @@ func IndexHandler(w http.ResponseWriter, r *http.Request) {
 
 // DownloadGeneralSimResultsHandler serves the latest general simulation results file.
 func DownloadGeneralSimResultsHandler(w http.ResponseWriter, r *http.Request) {
-	latestGeneralSimFileMutex.RLock()
-	currentFilePath := latestGeneralSimFilePath
-	latestGeneralSimFileMutex.RUnlock()
+	currentFilePath, _ := sessionFor(w, r).filePaths()
 
 	if currentFilePath == "" {
 		http.Error(w, "No general simulation results saved yet. Run a general simulation first.", http.StatusNotFound)
@@ -274,9 +492,7 @@ func DownloadGeneralSimResultsHandler(w http.ResponseWriter, r *http.Request) {
 
 // DownloadCDMASimResultsHandler serves the latest CDMA simulation results file.
 func DownloadCDMASimResultsHandler(w http.ResponseWriter, r *http.Request) {
-	latestCDMASimFileMutex.RLock()
-	currentFilePath := latestCDMASimFilePath
-	latestCDMASimFileMutex.RUnlock()
+	_, currentFilePath := sessionFor(w, r).filePaths()
 
 	if currentFilePath == "" {
 		http.Error(w, "No CDMA simulation results saved yet. Run a CDMA simulation first.", http.StatusNotFound)
@@ -298,7 +514,7 @@ func serveFileForDownload(w http.ResponseWriter, r *http.Request, filePath strin
 	http.ServeFile(w, r, filePath)
 }
 
-// Handle the simulation endpoint - runs complete simulation pipeline and stores results globally
+// Handle the simulation endpoint - runs complete simulation pipeline and stores results in the session
 func SimulateHandler(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
 		log.Printf("Form parse error: %v", err)
@@ -314,29 +530,16 @@ func SimulateHandler(w http.ResponseWriter, r *http.Request) {
 	goldNStr := strings.TrimSpace(r.FormValue("goldN"))
 	goldTaps1Str := strings.TrimSpace(r.FormValue("goldTaps1"))
 	goldTaps2Str := strings.TrimSpace(r.FormValue("goldTaps2"))
+	codeFamily := parseCodeFamily(r.FormValue("codeFamily"))
 	decoderType := strings.TrimSpace(r.FormValue("decoderType"))
-
-	errorEnabled := r.FormValue("errorEnabled") == "on"
-	decoderEnabled := r.FormValue("decoderEnabled") == "on"
-	berEnabled := r.FormValue("berEnabled") == "on"
-	autocorrEnabled := r.FormValue("autocorrEnabled") == "on"
-
-	var bitSeq *simulation.BitSequence
-	if seqType == "text" {
-		bitSeq = simulation.StringAsSequence(seqText)
-	} else {
-		seqLength := 64
-		if seqLengthStr != "" {
-			if parsed, err := strconv.Atoi(seqLengthStr); err == nil && parsed > 0 {
-				seqLength = parsed
-			}
-		}
-		if seqType == "random-text" {
-			seqType = "text"
-			seqText = simulation.RandomText(seqLength)
-			bitSeq = simulation.StringAsSequence(seqText)
-		} else {
-			bitSeq = simulation.RandomSequence(seqLength)
+	spreadingMode := strings.TrimSpace(r.FormValue("spreadingMode"))
+	spreadingFactorStr := strings.TrimSpace(r.FormValue("spreadingFactor"))
+	codePhaseOffsetStr := strings.TrimSpace(r.FormValue("codePhaseOffset"))
+
+	seqLength := 64
+	if seqLengthStr != "" {
+		if parsed, err := strconv.Atoi(seqLengthStr); err == nil && parsed > 0 {
+			seqLength = parsed
 		}
 	}
 
@@ -347,18 +550,25 @@ func SimulateHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	taps1 := []uint{0, 3}
-	if goldTaps1Str != "" {
-		if parsed := parseTaps(goldTaps1Str); len(parsed) > 0 {
-			taps1 = parsed
-		}
+	taps1, err := parseTapsWithDefault(goldTaps1Str, []uint{0, 3})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
 	}
 
-	taps2 := []uint{0, 2, 3, 8}
-	if goldTaps2Str != "" {
-		if parsed := parseTaps(goldTaps2Str); len(parsed) > 0 {
-			taps2 = parsed
-		}
+	taps2, err := parseTapsWithDefault(goldTaps2Str, []uint{0, 2, 3, 8})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !simulation.CodeFamilySupported(codeFamily, uint(n)) {
+		http.Error(w, codeFamilyError(codeFamily, uint(n)), http.StatusBadRequest)
+		return
+	}
+	if message := formTapsError(codeFamily, uint(n), taps1, taps2); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
 	}
 
 	errorRate := 5.0
@@ -368,66 +578,378 @@ func SimulateHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	if errorType == "" {
-		errorType = "random"
+	switch errorType {
+	case simulation.ErrorModelBurst, simulation.ErrorModelExact, simulation.ErrorModelGilbertElliott, simulation.ErrorModelErasure:
+	default:
+		errorType = simulation.ErrorModelRandom
+	}
+
+	slipRepeat := r.FormValue("slipDirection") == "repeat"
+
+	burstDistribution := r.FormValue("burstDistribution")
+	if burstDistribution != simulation.BurstGeometric {
+		burstDistribution = simulation.BurstFixed
+	}
+
+	if _, ok := simulation.LookupDecoder(decoderType); !ok {
+		decoderType = "" // Default of the spreading mode
+	}
+
+	codePhaseOffset := 0
+	if codePhaseOffsetStr != "" {
+		if parsed, err := strconv.Atoi(codePhaseOffsetStr); err == nil {
+			codePhaseOffset = parsed
+		}
+	}
+
+	if spreadingMode != simulation.SpreadingModeDSSS {
+		spreadingMode = simulation.SpreadingModeScramble
+	}
+
+	spreadingFactor := 0 // One code period
+	if spreadingFactorStr != "" {
+		if parsed, err := strconv.Atoi(spreadingFactorStr); err == nil && parsed > 0 {
+			spreadingFactor = parsed
+		}
+	}
+
+	params := generalSimParams{
+		SeqType:           seqType,
+		SeqText:           seqText,
+		SeqLength:         seqLength,
+		GoldN:             n,
+		GoldTaps1:         taps1,
+		GoldTaps2:         taps2,
+		CodeFamily:        codeFamily,
+		ErrorType:         errorType,
+		ErrorRate:         errorRate,
+		BurstLength:       parseIntWithDefault(r.FormValue("burstLength"), simulation.DefaultBurstLength, 1, 1<<16),
+		BurstDistribution: burstDistribution,
+		GEGoodToBad:       parseFloatWithDefault(r.FormValue("geGoodToBad"), defaultGEGoodToBad, 0, 100),
+		GEBadToGood:       parseFloatWithDefault(r.FormValue("geBadToGood"), defaultGEBadToGood, 0, 100),
+		GEGoodErrorRate:   parseFloatWithDefault(r.FormValue("geGoodErrorRate"), defaultGEGoodErrorRate, 0, 100),
+		GEBadErrorRate:    parseFloatWithDefault(r.FormValue("geBadErrorRate"), defaultGEBadErrorRate, 0, 100),
+		InsertionRate:     parseFloatWithDefault(r.FormValue("insertionRate"), 0, 0, maxSyncErrorRate),
+		DeletionRate:      parseFloatWithDefault(r.FormValue("deletionRate"), 0, 0, maxSyncErrorRate),
+		SlipPeriod:        parseIntWithDefault(r.FormValue("slipPeriod"), 0, 0, math.MaxInt32),
+		SlipRepeat:        slipRepeat,
+		DecoderType:       decoderType,
+		CodePhaseOffset:   codePhaseOffset,
+		SpreadingMode:     spreadingMode,
+		SpreadingFactor:   spreadingFactor,
+		ErrorEnabled:      r.FormValue("errorEnabled") == "on",
+		DecoderEnabled:    r.FormValue("decoderEnabled") == "on",
+		BerEnabled:        r.FormValue("berEnabled") == "on",
+		AutocorrEnabled:   r.FormValue("autocorrEnabled") == "on",
+		ComplexityEnabled: r.FormValue("complexityEnabled") == "on",
+		RandomnessEnabled: r.FormValue("randomnessEnabled") == "on",
+		Seed:              parseSeedWithDefault(r.FormValue("seed")),
+	}
+	if bits := generalDataBits(params); bits > maxSeqLength {
+		http.Error(w, fmt.Sprintf("Ciąg danych miałby %d bitów, dozwolone jest najwyżej %d. Zmniejsz długość ciągu lub tekstu.", bits, maxSeqLength), http.StatusBadRequest)
+		return
+	}
+	if chips := generalChipCount(params); spreadingMode == simulation.SpreadingModeDSSS && chips > maxSpreadChips {
+		http.Error(w, fmt.Sprintf("Rozpraszanie DSSS dałoby %d chipów, dozwolone jest najwyżej %d. Zmniejsz długość ciągu lub współczynnik rozpraszania.", chips, maxSpreadChips), http.StatusBadRequest)
+		return
+	}
+	results := runGeneralSimulation(params)
+
+	session := sessionFor(w, r)
+	session.General.mutex.Lock()
+	session.General.setFrom(results)
+	session.General.mutex.Unlock()
+
+	if err := runHistory.Save(newGeneralRunRecord(session.ID, results)); err != nil {
+		log.Printf("Error saving simulation run to history: %v", err)
+	}
+
+	savedPath, err := SaveSimulationResultsToFile(results)
+	if err != nil {
+		log.Printf("Error saving simulation results to file: %v", err)
+	} else {
+		session.setGeneralFilePath(savedPath)
+	}
+
+	// Return success response with HTMX trigger event
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("HX-Trigger", "simulation-complete")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `<div class="success-message">Symulacja zakończona pomyślnie! Czas: %s</div>`,
+		time.Now().Format("15:04:05"))
+	seed1, seed2 := generalGoldSeeds(n)
+	writeWarnings(w, codeWarnings(codeFamily, uint(n), taps1, taps2, [][2]uint64{{seed1, seed2}}))
+}
+
+// generalSimParams holds the validated parameters of the general encode-corrupt-decode pipeline
+type generalSimParams struct {
+	SeqType           string // "random", "random-text" or "text"
+	SeqText           string
+	SeqLength         int
+	GoldN             int
+	GoldTaps1         []uint
+	GoldTaps2         []uint
+	CodeFamily        string  // simulation.CodeFamilyGold, ...KasamiSmall or ...KasamiLarge
+	ErrorType         string  // simulation.ErrorModelRandom, ...Burst, ...Exact, ...GilbertElliott or ...Erasure
+	ErrorRate         float64 // Percent
+	BurstLength       int
+	BurstDistribution string  // simulation.BurstFixed or simulation.BurstGeometric
+	GEGoodToBad       float64 // Gilbert-Elliott transition and error probabilities, percent
+	GEBadToGood       float64
+	GEGoodErrorRate   float64
+	GEBadErrorRate    float64
+	InsertionRate     float64 // Synchronization errors, percent
+	DeletionRate      float64
+	SlipPeriod        int    // Bits between clock slips, 0 for none
+	SlipRepeat        bool   // Slips repeat a bit instead of dropping it
+	DecoderType       string // Registered decoder type, empty for the default of the spreading mode
+	CodePhaseOffset   int    // Chips the transmitter code leads the receiver code by
+	SpreadingMode     string // simulation.SpreadingModeScramble or simulation.SpreadingModeDSSS
+	SpreadingFactor   int    // Chips per data bit in DSSS mode, 0 for one code period
+	ErrorEnabled      bool
+	DecoderEnabled    bool
+	BerEnabled        bool
+	AutocorrEnabled   bool
+	ComplexityEnabled bool
+	RandomnessEnabled bool
+	Seed              int64
+}
+
+// generalGoldSeeds returns the fixed LFSR seeds of the general pipeline
+func generalGoldSeeds(n int) (uint64, uint64) {
+	return 1, uint64(0b1010101010) & (1<<n - 1) // Fits the register for n < 10 too
+}
+
+// generalCodeIndex selects the code of a family without seeds used by the general pipeline:
+// u ⊕ w for the small Kasami set, v for the large one, W1 or C(2^n,1) for orthogonal codes,
+// which unlike W0 is not constant, and the code shifted by one chip for the other families
+const generalCodeIndex = 1
+
+// generalCode returns the spreading code of the general pipeline and its display name
+func generalCode(params generalSimParams) (*simulation.BitSequence, string) {
+	if simulation.CodeFamilyUsesSeeds(params.CodeFamily) {
+		seed1, seed2 := generalGoldSeeds(params.GoldN)
+		code := simulation.NewGoldCode(uint(params.GoldN), params.GoldTaps1, seed1, params.GoldTaps2, seed2)
+		return code.Chips(), code.Name()
+	}
+	codeSet := simulation.NewCodeSet(params.CodeFamily, uint(params.GoldN), params.GoldTaps1, params.GoldTaps2)
+	return codeSet.Code(generalCodeIndex), codeSet.Name(generalCodeIndex)
+}
+
+// maxSpreadChips bounds the chip sequence of the DSSS mode, the analysis modules are quadratic in its length.
+// It also bounds the chips all users of a CDMA simulation send, see cdmaChipCount.
+const maxSpreadChips = 1 << 18
+
+// maxSeqLength bounds the data bits of the general pipeline, the correlation buffers of the
+// analysis modules grow with the length of every sequence
+const maxSeqLength = 1 << 18
+
+// spreadingFactor returns the chips per data bit, 1 when the data is only scrambled
+func (p generalSimParams) spreadingFactor(codeLength int) int {
+	if p.SpreadingMode != simulation.SpreadingModeDSSS {
+		return 1
+	}
+	if p.SpreadingFactor > 0 {
+		return p.SpreadingFactor
+	}
+	return codeLength
+}
+
+// Defaults of the error model parameters shared by the form and the API, probabilities in percent
+const (
+	defaultGEGoodToBad     = 1.0
+	defaultGEBadToGood     = 10.0
+	defaultGEGoodErrorRate = 0.1
+	defaultGEBadErrorRate  = 50.0
+)
+
+// errorModel returns the error model of the parameters with probabilities as fractions
+func (p generalSimParams) errorModel() simulation.ErrorModel {
+	model := simulation.ErrorModel{Type: p.ErrorType, Rate: p.ErrorRate / 100.0}
+	switch p.ErrorType {
+	case simulation.ErrorModelBurst:
+		model.BurstLength = p.BurstLength
+		model.BurstDistribution = p.BurstDistribution
+	case simulation.ErrorModelGilbertElliott:
+		model.Rate = 0
+		model.GoodToBad = p.GEGoodToBad / 100.0
+		model.BadToGood = p.GEBadToGood / 100.0
+		model.GoodErrorRate = p.GEGoodErrorRate / 100.0
+		model.BadErrorRate = p.GEBadErrorRate / 100.0
+	}
+	return model
+}
+
+// maxSyncErrorRate bounds the insertion and deletion probabilities in percent, beyond it
+// the received sequence has little to do with the transmitted one
+const maxSyncErrorRate = 50.0
+
+// syncImpairment returns the synchronization errors of the parameters with probabilities as fractions
+func (p generalSimParams) syncImpairment() simulation.SyncImpairment {
+	return simulation.SyncImpairment{
+		InsertionRate: p.InsertionRate / 100.0,
+		DeletionRate:  p.DeletionRate / 100.0,
+		SlipPeriod:    p.SlipPeriod,
+		SlipRepeat:    p.SlipRepeat,
+	}
+}
+
+// decoderType returns the selected decoder type or the default of the spreading mode
+func (p generalSimParams) decoderType() string {
+	if p.DecoderType == "" {
+		return simulation.DefaultDecoderType(p.SpreadingMode)
+	}
+	return p.DecoderType
+}
+
+// preamble returns the known bits sent before the data, nil unless the enabled decoder synchronizes on them
+func (p generalSimParams) preamble(codeLength int) *simulation.BitSequence {
+	decoder, _ := simulation.LookupDecoder(p.decoderType())
+	if preambleDecoder, ok := decoder.(simulation.PreambleDecoder); ok && p.DecoderEnabled {
+		return preambleDecoder.Preamble(codeLength, p.spreadingFactor(codeLength))
+	}
+	return nil
+}
+
+// generalDataBits returns the length of the data sequence the parameters produce
+func generalDataBits(params generalSimParams) int {
+	if params.SeqType == "text" {
+		return 8 * len(params.SeqText)
+	}
+	return params.SeqLength
+}
+
+// generalChipCount returns the length of the encoded sequence the parameters produce
+func generalChipCount(params generalSimParams) int {
+	dataBits := generalDataBits(params)
+	code, _ := generalCode(params)
+	if preamble := params.preamble(code.Len()); preamble != nil {
+		dataBits += preamble.Len()
 	}
+	return dataBits * params.spreadingFactor(code.Len())
+}
+
+// runGeneralSimulation runs the complete general pipeline and returns fresh results
+func runGeneralSimulation(params generalSimParams) *SimulationResults {
+	seqType := params.SeqType
+	seqText := params.SeqText
+	rng := rand.New(rand.NewSource(params.Seed))
 
-	if decoderType == "" {
-		decoderType = "xor"
+	var bitSeq *simulation.BitSequence
+	if seqType == "text" {
+		bitSeq = simulation.StringAsSequence(seqText)
+	} else if seqType == "random-text" {
+		seqType = "text"
+		seqText = simulation.RandomText(params.SeqLength, rng)
+		bitSeq = simulation.StringAsSequence(seqText)
+	} else {
+		bitSeq = simulation.RandomSequence(params.SeqLength, rng)
 	}
 
-	seed1 := uint64(1)
-	seed2 := uint64(0b1010101010)
-	goldCode := simulation.GenerateGoldCode(uint(n), taps1, seed1, taps2, seed2)
+	goldCode, codeName := generalCode(params)
+	spreadingFactor := params.spreadingFactor(goldCode.Len())
+	dsss := params.SpreadingMode == simulation.SpreadingModeDSSS
+
+	// The transmitter runs its code CodePhaseOffset chips ahead of the receiver
+	// and sends the preamble of a synchronizing decoder before the data
+	txCode := goldCode
+	if params.CodePhaseOffset != 0 {
+		txCode = goldCode.Rotate(params.CodePhaseOffset)
+	}
+	transmitted := bitSeq
+	preamble := params.preamble(goldCode.Len())
+	if preamble != nil {
+		transmitted = simulation.Concat(preamble, bitSeq)
+	}
 
 	var encoded *simulation.BitSequence
-	if goldCode != nil {
-		encodedTmp := simulation.EncodeWithGold(*bitSeq, *goldCode)
+	if goldCode != nil && dsss {
+		encoded = simulation.SpreadWithCode(transmitted, txCode, spreadingFactor)
+	} else if goldCode != nil {
+		encodedTmp := simulation.EncodeWithGold(*transmitted, *txCode)
 		encoded = encodedTmp
 	} else {
 		encoded = nil
 	}
 
-	var corrupted *simulation.BitSequence
-	var errorsIntroduced int
-	if errorEnabled && encoded != nil {
-		errorRateDecimal := errorRate / 100.0
-		corruptedTmp, errors := simulation.AddErrors(encoded, errorRateDecimal, errorType)
-		corrupted = corruptedTmp
-		errorsIntroduced = errors
+	// The Gilbert-Elliott channel has no rate parameter, its long-run error rate is recorded instead
+	errorModel := params.errorModel()
+	errorRate := params.ErrorRate
+	if errorModel.Type == simulation.ErrorModelGilbertElliott {
+		errorRate = errorModel.ExpectedRate() * 100
+	}
+	// The receiver keeps the positions the erasure channel erased as unknown
+	var received *simulation.ErasureSequence
+	var errorsIntroduced, erasuresIntroduced int
+	if params.ErrorEnabled && encoded != nil {
+		received, errorsIntroduced = simulation.ApplyChannel(encoded, errorModel, rng)
+		erasuresIntroduced = received.ErasureCount()
 	} else if encoded != nil {
-		corrupted = encoded
-		errorsIntroduced = 0
-	} else {
-		corrupted = nil
-		errorsIntroduced = 0
+		received = simulation.NewErasureSequence(encoded)
+	}
+
+	// Bits lost or gained by the receiver clock shift everything after them
+	var syncImpairment simulation.SyncImpairment
+	var syncStats simulation.SyncStats
+	if params.ErrorEnabled && encoded != nil && params.syncImpairment().Active() {
+		syncImpairment = params.syncImpairment()
+		received, syncStats = simulation.ApplySyncImpairmentsWithErasures(received, syncImpairment, rng)
+	}
+
+	var corrupted, corruptedErased *simulation.BitSequence
+	if received != nil {
+		corrupted = received.Bits
+		if received.ErasureCount() > 0 {
+			corruptedErased = received.Erased
+		}
 	}
 
-	var decoded *simulation.BitSequence
-	if decoderEnabled && corrupted != nil && goldCode != nil {
-		decodedTmp := simulation.DecodeWithGold(*corrupted, *goldCode)
-		decoded = decodedTmp
+	var chipErrorRate float32
+	if encoded != nil && encoded.Len() > 0 {
+		chipErrorRate = float32(errorsIntroduced) / float32(encoded.Len())
+	}
+
+	decoderType := params.decoderType()
+	var decoded, decodedErased *simulation.BitSequence
+	var correlations []float64
+	estimatedCodePhase := -1
+	if params.DecoderEnabled && corrupted != nil && goldCode != nil {
+		decoder, _ := simulation.LookupDecoder(decoderType)
+		// A shortened reception still has to fill the preamble and one bit
+		padded := simulation.PadErasureChips(received, spreadingFactor, (sequenceLength(preamble)+1)*spreadingFactor)
+		decodeResult := simulation.DecodeReceived(decoder, padded, goldCode, spreadingFactor)
+		decoded = decodeResult.Bits
+		correlations = decodeResult.Correlations
+		estimatedCodePhase = decodeResult.CodePhase
+		if decodeResult.Erased != nil && decodeResult.Erased.OnesCount() > 0 {
+			decodedErased = decodeResult.Erased
+		}
 	} else {
 		decoded = nil
 	}
 
+	// Erased bits are counted apart from the errors and left out of the BER
 	var ber float32
-	var errorCount int
-	if berEnabled && decoded != nil {
+	var errorCount, erasureCount int
+	var alignment *simulation.Alignment
+	if params.BerEnabled && decoded != nil && decodedErased != nil {
+		errorCount, erasureCount = simulation.CountErasuresAndErrors(bitSeq, decoded, decodedErased)
+		ber = float32(errorCount) / float32(max(bitSeq.Len(), decoded.Len()))
+	} else if params.BerEnabled && decoded != nil {
 		ber = simulation.CalculateBER(*bitSeq, *decoded)
-		errorCount = 0
-		for i := range bitSeq.Len() {
-			if bitSeq.Get(i) != decoded.Get(i) {
-				errorCount++
+		errorCount = simulation.CountBitErrors(bitSeq, decoded)
+	}
+	if params.BerEnabled && decoded != nil {
+		if syncImpairment.Active() {
+			if aligned, ok := simulation.AlignSequences(bitSeq, decoded); ok {
+				alignment = &aligned
 			}
 		}
-	} else {
-		ber = 0
-		errorCount = 0
 	}
 
-	var originalAutocorr, encodedAutocorr, corruptedAutocorr float32
-	if autocorrEnabled {
+	var codeAutocorr, originalAutocorr, encodedAutocorr, corruptedAutocorr float32
+	if params.AutocorrEnabled {
+		codeAutocorr = simulation.MaxAbsoluteOffPeak(simulation.CalculatePeriodicAutocorrelation(*goldCode))
 		originalAutocorr = simulation.MaxAbsoluteOffPeak(simulation.CalculatePeriodicAutocorrelation(*bitSeq))
 		if encoded != nil {
 			encodedAutocorr = simulation.MaxAbsoluteOffPeak(simulation.CalculatePeriodicAutocorrelation(*encoded))
@@ -437,64 +959,147 @@ func SimulateHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	globalResults.mutex.Lock()
-	globalResults.Original = bitSeq
-	globalResults.GoldCode = goldCode
-	globalResults.Encoded = encoded
-	globalResults.Corrupted = corrupted
-	globalResults.Decoded = decoded
-	globalResults.BER = ber
-	globalResults.ErrorCount = errorCount
+	var codeComplexity, originalComplexity, encodedComplexity, corruptedComplexity *simulation.LinearComplexity
+	if params.ComplexityEnabled {
+		codeComplexity = simulation.BerlekampMassey(goldCode)
+		originalComplexity = simulation.BerlekampMassey(bitSeq)
+		if encoded != nil {
+			encodedComplexity = simulation.BerlekampMassey(encoded)
+		}
+		if corrupted != nil {
+			corruptedComplexity = simulation.BerlekampMassey(corrupted)
+		}
+	}
+
+	var codeRandomness, originalRandomness, encodedRandomness []simulation.RandomnessTestResult
+	if params.RandomnessEnabled {
+		codeRandomness = simulation.RunRandomnessTests(goldCode)
+		originalRandomness = simulation.RunRandomnessTests(bitSeq)
+		if encoded != nil {
+			encodedRandomness = simulation.RunRandomnessTests(encoded)
+		}
+	}
+
+	results := &SimulationResults{
+		Original:            bitSeq,
+		GoldCode:            goldCode,
+		Encoded:             encoded,
+		Corrupted:           corrupted,
+		Decoded:             decoded,
+		BER:                 ber,
+		ErrorCount:          errorCount,
+		ErrorType:           params.ErrorType,
+		ErrorRate:           errorRate,
+		ErrorModel:          errorModel,
+		ErrorsIntroduced:    errorsIntroduced,
+		ErrorEnabled:        params.ErrorEnabled,
+		Timestamp:           time.Now().Format(time.RFC1123),
+		GoldN:               params.GoldN,
+		GoldTaps1:           params.GoldTaps1,
+		GoldTaps2:           params.GoldTaps2,
+		CodeFamily:          params.CodeFamily,
+		CodeName:            codeName,
+		DecoderType:         decoderType,
+		SpreadingMode:       params.SpreadingMode,
+		CodePhaseOffset:     params.CodePhaseOffset,
+		EstimatedCodePhase:  estimatedCodePhase,
+		PreambleLength:      sequenceLength(preamble),
+		Correlations:        correlations,
+		CorruptedErased:     corruptedErased,
+		ErasuresIntroduced:  erasuresIntroduced,
+		DecodedErased:       decodedErased,
+		ErasureCount:        erasureCount,
+		SyncImpairment:      syncImpairment,
+		SyncStats:           syncStats,
+		Alignment:           alignment,
+		SpreadingFactor:     spreadingFactor,
+		ChipErrorRate:       chipErrorRate,
+		CodeAutocorr:        codeAutocorr,
+		OriginalAutocorr:    originalAutocorr,
+		EncodedAutocorr:     encodedAutocorr,
+		CorruptedAutocorr:   corruptedAutocorr,
+		CodeComplexity:      codeComplexity,
+		OriginalComplexity:  originalComplexity,
+		EncodedComplexity:   encodedComplexity,
+		CorruptedComplexity: corruptedComplexity,
+		CodeRandomness:      codeRandomness,
+		OriginalRandomness:  originalRandomness,
+		EncodedRandomness:   encodedRandomness,
+		Seed:                params.Seed,
+	}
 	if seqType == "text" {
-		globalResults.InputText = seqText
-	} else {
-		globalResults.InputText = ""
-	}
-	globalResults.ErrorType = errorType
-	globalResults.ErrorRate = errorRate
-	globalResults.ErrorsIntroduced = errorsIntroduced
-	globalResults.Timestamp = time.Now().Format(time.RFC1123)
-	globalResults.GoldN = n
-	globalResults.GoldTaps1 = taps1
-	globalResults.GoldTaps2 = taps2
-	globalResults.DecoderType = decoderType
-	globalResults.OriginalAutocorr = originalAutocorr
-	globalResults.EncodedAutocorr = encodedAutocorr
-	globalResults.CorruptedAutocorr = corruptedAutocorr
-	globalResults.mutex.Unlock()
-
-	savedPath, err := SaveSimulationResultsToFile(globalResults)
-	if err != nil {
-		log.Printf("Error saving simulation results to file: %v", err)
-	} else {
-		latestGeneralSimFileMutex.Lock()
-		latestGeneralSimFilePath = savedPath
-		latestGeneralSimFileMutex.Unlock()
+		results.InputText = seqText
 	}
+	return results
+}
 
-	// Return success response with HTMX trigger event
-	w.Header().Set("Content-Type", "text/html")
-	w.Header().Set("HX-Trigger", "simulation-complete")
-	w.WriteHeader(http.StatusOK)
-	fmt.Fprintf(w, `<div class="success-message">Symulacja zakończona pomyślnie! Czas: %s</div>`,
-		time.Now().Format("15:04:05"))
+// setFrom copies all result fields of other into r. The caller must hold r.mutex.
+func (r *SimulationResults) setFrom(other *SimulationResults) {
+	r.Original = other.Original
+	r.GoldCode = other.GoldCode
+	r.Encoded = other.Encoded
+	r.Corrupted = other.Corrupted
+	r.Decoded = other.Decoded
+	r.BER = other.BER
+	r.ErrorCount = other.ErrorCount
+	r.InputText = other.InputText
+	r.ErrorType = other.ErrorType
+	r.ErrorRate = other.ErrorRate
+	r.ErrorModel = other.ErrorModel
+	r.ErrorsIntroduced = other.ErrorsIntroduced
+	r.ErrorEnabled = other.ErrorEnabled
+	r.Timestamp = other.Timestamp
+	r.GoldN = other.GoldN
+	r.GoldTaps1 = other.GoldTaps1
+	r.GoldTaps2 = other.GoldTaps2
+	r.CodeFamily = other.CodeFamily
+	r.CodeName = other.CodeName
+	r.DecoderType = other.DecoderType
+	r.CodePhaseOffset = other.CodePhaseOffset
+	r.EstimatedCodePhase = other.EstimatedCodePhase
+	r.PreambleLength = other.PreambleLength
+	r.Correlations = other.Correlations
+	r.CorruptedErased = other.CorruptedErased
+	r.ErasuresIntroduced = other.ErasuresIntroduced
+	r.DecodedErased = other.DecodedErased
+	r.ErasureCount = other.ErasureCount
+	r.SyncImpairment = other.SyncImpairment
+	r.SyncStats = other.SyncStats
+	r.Alignment = other.Alignment
+	r.SpreadingMode = other.SpreadingMode
+	r.SpreadingFactor = other.SpreadingFactor
+	r.ChipErrorRate = other.ChipErrorRate
+	r.CodeAutocorr = other.CodeAutocorr
+	r.OriginalAutocorr = other.OriginalAutocorr
+	r.EncodedAutocorr = other.EncodedAutocorr
+	r.CorruptedAutocorr = other.CorruptedAutocorr
+	r.CodeComplexity = other.CodeComplexity
+	r.OriginalComplexity = other.OriginalComplexity
+	r.EncodedComplexity = other.EncodedComplexity
+	r.CorruptedComplexity = other.CorruptedComplexity
+	r.CodeRandomness = other.CodeRandomness
+	r.OriginalRandomness = other.OriginalRandomness
+	r.EncodedRandomness = other.EncodedRandomness
+	r.Seed = other.Seed
 }
 
 func GeneratorHandler(w http.ResponseWriter, r *http.Request) {
-	globalResults.mutex.RLock()
+	results := sessionFor(w, r).General
+	results.mutex.RLock()
 
-	if globalResults.Original == nil {
-		globalResults.mutex.RUnlock()
+	if results.Original == nil {
+		results.mutex.RUnlock()
 		http.Error(w, "No simulation results available. Please run complete simulation first.", http.StatusBadRequest)
 		return
 	}
 
 	data := GeneratorData{
-		BitSequence: globalResults.Original.String(),
-		InputText:   globalResults.InputText,
-		Length:      globalResults.Original.Len(),
+		BitSequence: results.Original.String(),
+		InputText:   results.InputText,
+		Length:      results.Original.Len(),
+		Seed:        results.Seed,
 	}
-	globalResults.mutex.RUnlock()
+	results.mutex.RUnlock()
 
 	tmpl, err := template.ParseFiles("templates/generator_result.html")
 	if err != nil {
@@ -511,23 +1116,32 @@ func GeneratorHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func EncoderHandler(w http.ResponseWriter, r *http.Request) {
-	globalResults.mutex.RLock()
+	results := sessionFor(w, r).General
+	results.mutex.RLock()
 
-	if globalResults.GoldCode == nil || globalResults.Encoded == nil {
-		globalResults.mutex.RUnlock()
+	if results.GoldCode == nil || results.Encoded == nil {
+		results.mutex.RUnlock()
 		http.Error(w, "No simulation results available. Please run complete simulation first.", http.StatusBadRequest)
 		return
 	}
 
 	data := EncoderData{
-		GoldCode:        globalResults.GoldCode.String(),
-		EncodedSequence: globalResults.Encoded.String(),
-		N:               globalResults.GoldN,
-		Length:          globalResults.GoldCode.Len(),
-		Taps1:           globalResults.GoldTaps1,
-		Taps2:           globalResults.GoldTaps2,
-	}
-	globalResults.mutex.RUnlock()
+		GoldCode:        results.GoldCode.String(),
+		EncodedSequence: results.Encoded.String(),
+		N:               results.GoldN,
+		Length:          results.GoldCode.Len(),
+		FamilyLabel:     codeFamilyLabel(results.CodeFamily),
+		CodeName:        results.CodeName,
+		UsesLFSR1:       simulation.CodeFamilyUsesLFSR1(results.CodeFamily),
+		UsesLFSR2:       simulation.CodeFamilyUsesSeeds(results.CodeFamily),
+		Taps1:           results.GoldTaps1,
+		Taps2:           results.GoldTaps2,
+		DSSS:            results.SpreadingMode == simulation.SpreadingModeDSSS,
+		SpreadingFactor: results.SpreadingFactor,
+		ProcessingGain:  fmt.Sprintf("%.2f", simulation.ProcessingGain(results.SpreadingFactor)),
+		PreambleLength:  results.PreambleLength,
+	}
+	results.mutex.RUnlock()
 
 	tmpl, err := template.ParseFiles("templates/encoder_result.html")
 	if err != nil {
@@ -544,26 +1158,36 @@ func EncoderHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func ErrorHandler(w http.ResponseWriter, r *http.Request) {
-	globalResults.mutex.RLock()
-	if globalResults.Encoded != nil && globalResults.Corrupted == globalResults.Encoded {
-		globalResults.mutex.RUnlock()
+	results := sessionFor(w, r).General
+	results.mutex.RLock()
+	if results.Encoded != nil && !results.ErrorEnabled {
+		results.mutex.RUnlock()
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprint(w, `<div class="module-disabled-message">Moduł dodawania błędów jest wyłączony.</div>`)
 		return
 	}
-	if globalResults.Corrupted == nil {
-		globalResults.mutex.RUnlock()
+	if results.Corrupted == nil {
+		results.mutex.RUnlock()
 		http.Error(w, "No simulation results available. Please run complete simulation first.", http.StatusBadRequest)
 		return
 	}
 
 	data := ErrorData{
-		CorruptedSequence: globalResults.Corrupted.String(),
-		ErrorType:         globalResults.ErrorType,
-		ErrorRate:         globalResults.ErrorRate,
-		ErrorsIntroduced:  globalResults.ErrorsIntroduced,
-	}
-	globalResults.mutex.RUnlock()
+		CorruptedSequence:  simulation.MarkErasures(results.Corrupted, results.CorruptedErased),
+		ErrorType:          results.ErrorType,
+		ErrorRate:          results.ErrorRate,
+		ErrorsIntroduced:   results.ErrorsIntroduced,
+		ChipLevel:          results.SpreadingMode == simulation.SpreadingModeDSSS,
+		ModelDescription:   errorModelDescription(results.ErrorModel),
+		Erasure:            results.ErrorModel.Type == simulation.ErrorModelErasure,
+		ErasuresIntroduced: results.ErasuresIntroduced,
+		SyncDescription:    syncDescription(results.SyncImpairment, symbolUnit(results.SpreadingMode)),
+		Insertions:         results.SyncStats.Insertions,
+		Deletions:          results.SyncStats.Deletions,
+		Slips:              results.SyncStats.Slips,
+		EncodedLength:      sequenceLength(results.Encoded),
+	}
+	results.mutex.RUnlock()
 
 	tmpl, err := template.ParseFiles("templates/error_result.html")
 	if err != nil {
@@ -578,28 +1202,43 @@ func ErrorHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func DecoderHandler(w http.ResponseWriter, r *http.Request) {
-	globalResults.mutex.RLock()
-	if globalResults.Decoded == nil {
-		globalResults.mutex.RUnlock()
+	results := sessionFor(w, r).General
+	results.mutex.RLock()
+	if results.Decoded == nil {
+		results.mutex.RUnlock()
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprint(w, `<div class="module-disabled-message">Moduł dekodera jest wyłączony.</div>`)
 		return
 	}
 
-	decodedBits := globalResults.Decoded.String()
+	decodedBits := results.Decoded.String()
 	ascii := ""
-	if globalResults.InputText != "" {
+	if results.InputText != "" {
 		ascii = bitsToASCII(decodedBits)
 	}
 
 	data := DecoderData{
-		DecodedSequence: decodedBits,
-		DecoderType:     globalResults.DecoderType,
-		DecodedASCII:    ascii,
+		DecodedSequence:    simulation.MarkErasures(results.Decoded, results.DecodedErased),
+		ErasedBits:         sequenceOnes(results.DecodedErased),
+		DecoderType:        results.DecoderType,
+		DecoderLabel:       decoderLabel(results.DecoderType),
+		DecodedASCII:       ascii,
+		CodePhaseOffset:    results.CodePhaseOffset,
+		EstimatedCodePhase: results.EstimatedCodePhase,
+		PreambleLength:     results.PreambleLength,
+	}
+	if len(results.Correlations) > 0 {
+		minMargin := math.Inf(1)
+		for _, c := range results.Correlations {
+			minMargin = min(minMargin, math.Abs(c))
+		}
+		data.MeanMargin = fmt.Sprintf("%.4f", simulation.MeanAbsCorrelation(results.Correlations))
+		data.MinMargin = fmt.Sprintf("%.4f", minMargin)
+		data.Chart = buildLineChart([]ChartSeries{marginSeries(results.Correlations)}, "bit", "ρ", false)
 	}
-	globalResults.mutex.RUnlock()
+	results.mutex.RUnlock()
 
-	tmpl, err := template.ParseFiles("templates/decoder_result.html")
+	tmpl, err := template.ParseFiles("templates/decoder_result.html", "templates/chart.html")
 	if err != nil {
 		http.Error(w, "Template error", http.StatusInternalServerError)
 		return
@@ -612,40 +1251,61 @@ func DecoderHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func BERHandler(w http.ResponseWriter, r *http.Request) {
-	globalResults.mutex.RLock()
-	if globalResults.Original == nil {
-		globalResults.mutex.RUnlock()
+	results := sessionFor(w, r).General
+	results.mutex.RLock()
+	if results.Original == nil {
+		results.mutex.RUnlock()
 		http.Error(w, "No simulation results available. Please run complete simulation first.", http.StatusBadRequest)
 		return
 	}
-	if globalResults.Decoded == nil {
-		globalResults.mutex.RUnlock()
+	if results.Decoded == nil {
+		results.mutex.RUnlock()
 		w.Header().Set("Content-Type", "text/html")
 		msg := `<div class="module-disabled-message">Moduł BER wymaga działania modułu dekodera.</div>`
 		fmt.Fprint(w, msg)
 		return
 	}
 
-	origBits := globalResults.Original.String()
-	decBits := globalResults.Decoded.String()
+	origBits := results.Original.String()
+	decBits := results.Decoded.String()
 
 	origASCII := ""
 	decASCII := ""
-	if globalResults.InputText != "" {
+	if results.InputText != "" {
 		origASCII = bitsToASCII(origBits)
 		decASCII = bitsToASCII(decBits)
 	}
 
 	data := BERData{
-		BER:              fmt.Sprintf("%.2f", globalResults.BER*100),
-		ErrorsDetected:   globalResults.ErrorCount,
-		TotalBits:        globalResults.Original.Len(),
+		BER:              fmt.Sprintf("%.2f", results.BER*100),
+		ErrorsDetected:   results.ErrorCount,
+		TotalBits:        results.Original.Len(),
 		OriginalSequence: origBits,
 		DecodedSequence:  decBits,
 		OriginalASCII:    origASCII,
 		DecodedASCII:     decASCII,
-	}
-	globalResults.mutex.RUnlock()
+		DSSS:             results.SpreadingMode == simulation.SpreadingModeDSSS,
+		ChipErrorRate:    fmt.Sprintf("%.2f", results.ChipErrorRate*100),
+		SpreadingFactor:  results.SpreadingFactor,
+		ProcessingGain:   fmt.Sprintf("%.2f", simulation.ProcessingGain(results.SpreadingFactor)),
+		DecodedBits:      results.Decoded.Len(),
+		Sync:             results.SyncImpairment.Active(),
+	}
+	if results.DecodedErased != nil {
+		data.DecodedSequence = simulation.MarkErasures(results.Decoded, results.DecodedErased)
+		data.Erasure = true
+		data.Erasures = results.ErasureCount
+		data.ErasureRate = fmt.Sprintf("%.2f", 100*float64(results.ErasureCount)/float64(results.Original.Len()))
+	}
+	if alignment := results.Alignment; alignment != nil {
+		data.Aligned = true
+		data.EditDistance = alignment.Distance
+		data.Substitutions = alignment.Substitutions
+		data.Insertions = alignment.Insertions
+		data.Deletions = alignment.Deletions
+		data.AlignedBER = fmt.Sprintf("%.2f", 100*float64(alignment.Distance)/float64(results.Original.Len()))
+	}
+	results.mutex.RUnlock()
 
 	tmpl, err := template.ParseFiles("templates/ber_result.html")
 	if err != nil {
@@ -659,26 +1319,28 @@ func BERHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func AutocorrelationHandler(w http.ResponseWriter, r *http.Request) {
-	globalResults.mutex.RLock()
+	results := sessionFor(w, r).General
+	results.mutex.RLock()
 
-	if globalResults.Original == nil {
-		globalResults.mutex.RUnlock()
+	if results.Original == nil {
+		results.mutex.RUnlock()
 		http.Error(w, "No simulation results available. Please run complete simulation first.", http.StatusBadRequest)
 		return
 	}
-	if globalResults.OriginalAutocorr == 0 && globalResults.EncodedAutocorr == 0 && globalResults.CorruptedAutocorr == 0 {
-		globalResults.mutex.RUnlock()
+	if results.OriginalAutocorr == 0 && results.EncodedAutocorr == 0 && results.CorruptedAutocorr == 0 {
+		results.mutex.RUnlock()
 		w.Header().Set("Content-Type", "text/html")
 		fmt.Fprint(w, `<div class="module-disabled-message">Moduł autokorelacji jest wyłączony.</div>`)
 		return
 	}
 
 	data := AutocorrelationData{
-		OriginalMaxOffPeak:  fmt.Sprintf("%.4f", globalResults.OriginalAutocorr),
-		EncodedMaxOffPeak:   fmt.Sprintf("%.4f", globalResults.EncodedAutocorr),
-		CorruptedMaxOffPeak: fmt.Sprintf("%.4f", globalResults.CorruptedAutocorr),
+		CodeMaxOffPeak:      fmt.Sprintf("%.4f", results.CodeAutocorr),
+		OriginalMaxOffPeak:  fmt.Sprintf("%.4f", results.OriginalAutocorr),
+		EncodedMaxOffPeak:   fmt.Sprintf("%.4f", results.EncodedAutocorr),
+		CorruptedMaxOffPeak: fmt.Sprintf("%.4f", results.CorruptedAutocorr),
 	}
-	globalResults.mutex.RUnlock()
+	results.mutex.RUnlock()
 
 	tmpl, err := template.ParseFiles("templates/autocorrelation_result.html")
 	if err != nil {
@@ -694,6 +1356,264 @@ func AutocorrelationHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// randomnessTestLabels are the display names of the NIST SP 800-22 tests
+var randomnessTestLabels = map[string]string{
+	simulation.TestFrequency:          "Test częstości",
+	simulation.TestBlockFrequency:     "Test częstości w blokach",
+	simulation.TestRuns:               "Test serii",
+	simulation.TestLongestRun:         "Najdłuższa seria jedynek w bloku",
+	simulation.TestSpectral:           "Test spektralny (DFT)",
+	simulation.TestSerial1:            "Test par (∇ψ²)",
+	simulation.TestSerial2:            "Test par (∇²ψ²)",
+	simulation.TestApproximateEntropy: "Entropia przybliżona",
+	simulation.TestCumulativeSumsFwd:  "Sumy skumulowane (w przód)",
+	simulation.TestCumulativeSumsBwd:  "Sumy skumulowane (wstecz)",
+}
+
+func RandomnessHandler(w http.ResponseWriter, r *http.Request) {
+	results := sessionFor(w, r).General
+	results.mutex.RLock()
+
+	if results.Original == nil {
+		results.mutex.RUnlock()
+		http.Error(w, "No simulation results available. Please run complete simulation first.", http.StatusBadRequest)
+		return
+	}
+	if results.OriginalRandomness == nil {
+		results.mutex.RUnlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<div class="module-disabled-message">Moduł testów losowości jest wyłączony.</div>`)
+		return
+	}
+
+	columns := []struct {
+		label   string
+		results []simulation.RandomnessTestResult
+	}{
+		{"Kod rozpraszający", results.CodeRandomness},
+		{"Ciąg oryginalny", results.OriginalRandomness},
+		{"Ciąg zakodowany", results.EncodedRandomness},
+	}
+	data := RandomnessData{Alpha: simulation.RandomnessAlpha}
+	for _, column := range columns {
+		if column.results == nil {
+			continue
+		}
+		data.Sequences = append(data.Sequences, column.label)
+		passed, applicable := simulation.CountPassed(column.results)
+		data.Passed = append(data.Passed, fmt.Sprintf("%d / %d", passed, applicable))
+		for i, result := range column.results {
+			if i == len(data.Rows) {
+				data.Rows = append(data.Rows, RandomnessRow{Label: randomnessTestLabels[result.Test], Name: result.Name})
+			}
+			data.Rows[i].Results = append(data.Rows[i].Results, RandomnessCell{
+				PValue:     fmt.Sprintf("%.4f", result.PValue),
+				Passed:     result.Passed,
+				Applicable: result.Applicable,
+				Parameters: result.Parameters,
+			})
+		}
+	}
+	results.mutex.RUnlock()
+
+	tmpl, err := template.ParseFiles("templates/randomness_result.html")
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("Error executing randomness template: %v", err)
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	}
+}
+
+// errorModelDescription describes the parameters of an error model in Polish,
+// empty for runs saved before the model was stored
+func errorModelDescription(m simulation.ErrorModel) string {
+	switch m.Type {
+	case simulation.ErrorModelRandom:
+		return "Niezależne błędy bitów (kanał binarny symetryczny)"
+	case simulation.ErrorModelBurst:
+		if m.BurstDistribution == simulation.BurstGeometric {
+			return fmt.Sprintf("Rozłączne serie o geometrycznym rozkładzie długości, średnio %d bitów", m.BurstLength)
+		}
+		return fmt.Sprintf("Rozłączne serie o stałej długości %d bitów", m.BurstLength)
+	case simulation.ErrorModelExact:
+		return fmt.Sprintf("Dokładnie %.2f%% bitów z błędem na losowych pozycjach", m.Rate*100)
+	case simulation.ErrorModelErasure:
+		return fmt.Sprintf("Niezależne wymazania bitów z prawdopodobieństwem %.2f%% (kanał binarny z wymazaniami), odebrane bity są zawsze poprawne", m.Rate*100)
+	case simulation.ErrorModelGilbertElliott:
+		badStay := "∞"
+		if m.BadToGood > 0 {
+			badStay = fmt.Sprintf("%.1f", 1/m.BadToGood)
+		}
+		return fmt.Sprintf("Gilbert-Elliott: P(D→Z) = %.2f%%, P(Z→D) = %.2f%%, błędy w stanie dobrym %.2f%%, w złym %.2f%%; "+
+			"stan zły przez %.2f%% czasu, średnio %s bitów",
+			m.GoodToBad*100, m.BadToGood*100, m.GoodErrorRate*100, m.BadErrorRate*100, m.BadStateProbability()*100, badStay)
+	}
+	return ""
+}
+
+// syncDescription describes the synchronization errors in Polish, empty when none were applied.
+// Slip periods are counted in units, the chips of the DSSS mode or the bits otherwise.
+func syncDescription(s simulation.SyncImpairment, unit string) string {
+	if !s.Active() {
+		return ""
+	}
+	var parts []string
+	if s.InsertionRate > 0 {
+		parts = append(parts, fmt.Sprintf("wstawienia %.2f%%", s.InsertionRate*100))
+	}
+	if s.DeletionRate > 0 {
+		parts = append(parts, fmt.Sprintf("usunięcia %.2f%%", s.DeletionRate*100))
+	}
+	if s.SlipPeriod > 0 {
+		kind := "utrata bitu"
+		if s.SlipRepeat {
+			kind = "powtórzenie bitu"
+		}
+		parts = append(parts, fmt.Sprintf("poślizg zegara co %d %s (%s)", s.SlipPeriod, unit, kind))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// symbolUnit returns the Polish plural genitive of the transmitted symbols of a spreading mode
+func symbolUnit(spreadingMode string) string {
+	if spreadingMode == simulation.SpreadingModeDSSS {
+		return "chipów"
+	}
+	return "bitów"
+}
+
+// decoderLabels are the Polish names of the registered decoder types
+var decoderLabels = map[string]string{
+	simulation.DecoderXOR:      "Prosty XOR (1 chip na bit)",
+	simulation.DecoderMajority: "Większościowy (decyzja twarda)",
+	simulation.DecoderSoft:     "Korelacyjny (decyzja miękka)",
+	simulation.DecoderPreamble: "Synchronizacja fazy kodu preambułą",
+}
+
+// decoderLabel returns the Polish name of a decoder type, the type itself if it has none
+func decoderLabel(decoderType string) string {
+	if label, ok := decoderLabels[decoderType]; ok {
+		return label
+	}
+	return decoderType
+}
+
+// maxMarginPoints limits the points of the bit correlation chart of the decoder
+const maxMarginPoints = 400
+
+// marginSeries turns the bit correlations of a soft decoder into a chart line of at most
+// maxMarginPoints points, keeping the weakest decision of every bucket
+func marginSeries(correlations []float64) ChartSeries {
+	step := max(1, (len(correlations)+maxMarginPoints-1)/maxMarginPoints)
+	series := ChartSeries{Label: "korelacja bitu"}
+	for start := 0; start < len(correlations); start += step {
+		weakest := start
+		for i := start + 1; i < min(start+step, len(correlations)); i++ {
+			if math.Abs(correlations[i]) < math.Abs(correlations[weakest]) {
+				weakest = i
+			}
+		}
+		series.X = append(series.X, float64(weakest))
+		series.Y = append(series.Y, correlations[weakest])
+	}
+	return series
+}
+
+// maxProfilePoints limits the points of every linear complexity profile drawn in the chart
+const maxProfilePoints = 200
+
+// maxTapsComplexity is the longest register whose taps are listed next to the connection polynomial
+const maxTapsComplexity = 32
+
+func LinearComplexityHandler(w http.ResponseWriter, r *http.Request) {
+	results := sessionFor(w, r).General
+	results.mutex.RLock()
+
+	if results.Original == nil {
+		results.mutex.RUnlock()
+		http.Error(w, "No simulation results available. Please run complete simulation first.", http.StatusBadRequest)
+		return
+	}
+	if results.OriginalComplexity == nil {
+		results.mutex.RUnlock()
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, `<div class="module-disabled-message">Moduł złożoności liniowej jest wyłączony.</div>`)
+		return
+	}
+
+	sequences := []struct {
+		label      string
+		complexity *simulation.LinearComplexity
+	}{
+		{"Kod rozpraszający", results.CodeComplexity},
+		{"Ciąg oryginalny", results.OriginalComplexity},
+		{"Ciąg zakodowany", results.EncodedComplexity},
+		{"Ciąg z błędami", results.CorruptedComplexity},
+	}
+	var data LinearComplexityData
+	var series []ChartSeries
+	longest := 0
+	for _, seq := range sequences {
+		lc := seq.complexity
+		if lc == nil {
+			continue
+		}
+		row := LinearComplexityRow{
+			Label:      seq.label,
+			Length:     lc.Length,
+			Complexity: lc.Complexity,
+			Ratio:      fmt.Sprintf("%.4f", lc.Ratio()),
+			Connection: truncateString(lc.ConnectionString(), 120),
+		}
+		if lc.Complexity > 0 && lc.Complexity <= maxTapsComplexity {
+			row.Taps = joinTaps(lc.Taps())
+		}
+		data.Rows = append(data.Rows, row)
+		series = append(series, profileSeries(seq.label, lc.Profile))
+		longest = max(longest, lc.Length)
+	}
+	results.mutex.RUnlock()
+
+	// A random sequence follows k/2
+	series = append(series, ChartSeries{Label: "k/2", X: []float64{1, float64(longest)}, Y: []float64{0.5, float64(longest) / 2}})
+	data.Chart = buildLineChart(series, "k (liczba bitów)", "L(k)", false)
+
+	tmpl, err := template.ParseFiles("templates/linear_complexity_result.html", "templates/chart.html")
+	if err != nil {
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("Error executing linear complexity template: %v", err)
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	}
+}
+
+// profileSeries turns a linear complexity profile into a chart line of at most maxProfilePoints points,
+// always keeping the last one
+func profileSeries(label string, profile []int) ChartSeries {
+	step := max(1, (len(profile)+maxProfilePoints-1)/maxProfilePoints)
+	series := ChartSeries{Label: label}
+	for k := 0; k < len(profile); k += step {
+		series.X = append(series.X, float64(k+1))
+		series.Y = append(series.Y, float64(profile[k]))
+	}
+	if last := len(profile) - 1; last%step != 0 {
+		series.X = append(series.X, float64(last+1))
+		series.Y = append(series.Y, float64(profile[last]))
+	}
+	return series
+}
+
 func CDMASimulateHandler(w http.ResponseWriter, r *http.Request) {
 	if err := r.ParseForm(); err != nil {
 		log.Printf("CDMA Form parse error: %v", err)
@@ -705,176 +1625,298 @@ func CDMASimulateHandler(w http.ResponseWriter, r *http.Request) {
 		GoldNStr:           r.FormValue("cdmaGoldN"),
 		GoldTaps1Str:       r.FormValue("cdmaGoldTaps1"),
 		GoldTaps2Str:       r.FormValue("cdmaGoldTaps2"),
-		TextUserAStr:       strings.TrimSpace(r.FormValue("cdmaTextUserA")),
-		SeedA1Str:          r.FormValue("cdmaSeedA1"),
-		SeedA2Str:          r.FormValue("cdmaSeedA2"),
-		TextUserBStr:       strings.TrimSpace(r.FormValue("cdmaTextUserB")),
-		SeedB1Str:          r.FormValue("cdmaSeedB1"),
-		SeedB2Str:          r.FormValue("cdmaSeedB2"),
+		FamilyStr:          r.FormValue("cdmaCodeFamily"),
+		Users:              parseCDMAUserForms(r),
 		SeqLengthRandomStr: r.FormValue("cdmaSeqLengthRandom"),
 		NoiseLevelStr:      r.FormValue("cdmaNoiseLevel"),
 	}
 
-	goldN := uint(parseIntWithDefault(formData.GoldNStr, 4, 2, 16))
-	taps1 := parseTapsWithDefault(formData.GoldTaps1Str, []uint{0, 3})
-	taps2 := parseTapsWithDefault(formData.GoldTaps2Str, []uint{0, 2, 3})
-
-	seedA1 := parseUint64WithDefault(formData.SeedA1Str, 1)
-	seedA2 := parseUint64WithDefault(formData.SeedA2Str, 1)
-	seedB1 := parseUint64WithDefault(formData.SeedB1Str, 2)
-	seedB2 := parseUint64WithDefault(formData.SeedB2Str, 2)
+	goldN, taps1, taps2, err := parseCDMACodeConfig(formData)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	family := parseCodeFamily(formData.FamilyStr)
+	if !simulation.CodeFamilySupported(family, goldN) {
+		http.Error(w, codeFamilyError(family, goldN), http.StatusBadRequest)
+		return
+	}
+	if message := formTapsError(family, goldN, taps1, taps2); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+	users, err := cdmaUserConfigs(formData.Users, family, goldN)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	seqLengthRandomBytes := parseIntWithDefault(formData.SeqLengthRandomStr, 1, 1, 10)
 	seqLengthRandomBits := seqLengthRandomBytes * 8
+	if chips := cdmaChipCount(family, goldN, users, seqLengthRandomBits); chips > maxSpreadChips {
+		http.Error(w, fmt.Sprintf("Użytkownicy wysłaliby %d chipów, dozwolone jest najwyżej %d. Skróć teksty, zmniejsz liczbę użytkowników lub wybierz krótsze kody.", chips, maxSpreadChips), http.StatusBadRequest)
+		return
+	}
 
 	noiseLevelPercent := parseFloatWithDefault(formData.NoiseLevelStr, 100.0, 0.0, math.MaxFloat64)
 	noiseLevel := noiseLevelPercent / 100.0
 
-	simResult := simulation.SimulateCDMA(
-		goldN, taps1, taps2,
-		seedA1, seedA2, formData.TextUserAStr,
-		seedB1, seedB2, formData.TextUserBStr,
-		seqLengthRandomBits,
-		noiseLevel,
-	)
+	seed := parseSeedWithDefault(r.FormValue("cdmaSeed"))
+
+	simResult := simulation.SimulateCDMA(goldN, taps1, taps2, family, users, seqLengthRandomBits, noiseLevel, seed)
 
 	simResult.NoiseLevel = noiseLevelPercent
 
-	cdmaGlobalState.mutex.Lock()
-	cdmaGlobalState.Timestamp = simResult.Timestamp
-	cdmaGlobalState.GlobalN = simResult.N
-	cdmaGlobalState.GlobalPoly1 = simResult.Poly1
-	cdmaGlobalState.GlobalPoly2 = simResult.Poly2
-	cdmaGlobalState.InputTextA = simResult.InputTextA
-	cdmaGlobalState.SeedA1_form = simResult.SeedA1
-	cdmaGlobalState.SeedA2_form = simResult.SeedA2
-	if simResult.OriginalDataSeqA != nil {
-		cdmaGlobalState.OriginalDataStrA = simResult.OriginalDataSeqA.String()
-	}
-	if simResult.EncodedDataSeqA != nil {
-		cdmaGlobalState.EncodedDataStrA = simResult.EncodedDataSeqA.String()
-	}
-	cdmaGlobalState.DataLengthA = simResult.DataBitLengthUserA
-	cdmaGlobalState.GeneratedGoldCodeA = simResult.GoldCodeAStr
-
-	cdmaGlobalState.InputTextB = simResult.InputTextB
-	cdmaGlobalState.SeedB1_form = simResult.SeedB1
-	cdmaGlobalState.SeedB2_form = simResult.SeedB2
-	if simResult.OriginalDataSeqB != nil {
-		cdmaGlobalState.OriginalDataStrB = simResult.OriginalDataSeqB.String()
-	}
-	if simResult.EncodedDataSeqB != nil {
-		cdmaGlobalState.EncodedDataStrB = simResult.EncodedDataSeqB.String()
-	}
-	cdmaGlobalState.DataLengthB = simResult.DataBitLengthUserB
-	cdmaGlobalState.GeneratedGoldCodeB = simResult.GoldCodeBStr
-
-	cdmaGlobalState.SimulationDataLength = simResult.SimulationDataLength
-	cdmaGlobalState.FullTransmittedSignalLength = simResult.FullTransmittedSignalLength
-	cdmaGlobalState.NoiseLevel_form = simResult.NoiseLevel
-	cdmaGlobalState.TransmittedSignalAStr = simResult.TransmittedSignalAStr
-	cdmaGlobalState.TransmittedSignalBStr = simResult.TransmittedSignalBStr
-	cdmaGlobalState.CombinedSignalStr = simResult.CombinedSignalStr
-	cdmaGlobalState.ReceivedSignalStr = simResult.ReceivedSignalStr
-	cdmaGlobalState.GoldCodeLength = simResult.GoldCodeLength
-	cdmaGlobalState.ReceivedSignalSegmentAStr = simResult.ReceivedSignalSegmentAStr
-	cdmaGlobalState.ReceivedSignalSegmentBStr = simResult.ReceivedSignalSegmentBStr
-	cdmaGlobalState.CorrelatedSignalAStr = simResult.CorrelatedSignalUserAStr
-	cdmaGlobalState.CorrelatedSignalBStr = simResult.CorrelatedSignalUserBStr
-
-	cdmaGlobalState.DecodedTextA = simResult.DecodedTextA
-	if simResult.DecodedDataSeqA != nil {
-		cdmaGlobalState.DecodedDataStrA = simResult.DecodedDataSeqA.String()
-	}
-	cdmaGlobalState.ErrorCountA = simResult.ErrorCountA
-	cdmaGlobalState.BER_A_str = fmt.Sprintf("%.2f%%", simResult.BER_A*100)
-
-	cdmaGlobalState.DecodedTextB = simResult.DecodedTextB
-	if simResult.DecodedDataSeqB != nil {
-		cdmaGlobalState.DecodedDataStrB = simResult.DecodedDataSeqB.String()
-	}
-	cdmaGlobalState.ErrorCountB = simResult.ErrorCountB
-	cdmaGlobalState.BER_B_str = fmt.Sprintf("%.2f%%", simResult.BER_B*100)
-
-	cdmaGlobalState.AutocorrelationPeak = simResult.AutocorrelationPeak
-	cdmaGlobalState.MaxOffPeakAutocorrelationA = simResult.MaxOffPeakAutocorrelationA
-	cdmaGlobalState.MaxOffPeakAutocorrelationB = simResult.MaxOffPeakAutocorrelationB
-	cdmaGlobalState.CrossCorrelationAB = simResult.CrossCorrelationAB
-	cdmaGlobalState.mutex.Unlock()
+	session := sessionFor(w, r)
+	session.CDMA.mutex.Lock()
+	session.CDMA.setFromResult(simResult)
+	warnings := session.CDMA.TapsWarnings
+	session.CDMA.mutex.Unlock()
+
+	if err := runHistory.Save(newCDMARunRecord(session.ID, simResult)); err != nil {
+		log.Printf("Error saving CDMA run to history: %v", err)
+	}
 
 	savedPath, err := SaveCDMAResultsToFile(simResult)
 	if err == nil {
-		latestCDMASimFileMutex.Lock()
-		latestCDMASimFilePath = savedPath
-		latestCDMASimFileMutex.Unlock()
+		session.setCDMAFilePath(savedPath)
 	}
 
 	w.Header().Set("Content-Type", "text/html")
 	w.Header().Set("HX-Trigger", "cdma-simulation-complete")
 	w.WriteHeader(http.StatusOK)
 	fmt.Fprintf(w, `<div class="success-message">Symulacja CDMA zakończona pomyślnie! Czas: %s</div>`, time.Now().Format("15:04:05"))
+	writeWarnings(w, warnings)
 }
 
-// Add new BER handlers for individual users
-func CDMABERAResultsHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
-		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
-		return
+// setFromResult replaces the displayed state with the given simulation result. The caller must hold s.mutex.
+func (s *CDMASimulationState) setFromResult(simResult *simulation.CDMAResult) {
+	userStates := make([]CDMAUserState, len(simResult.Users))
+	for i, user := range simResult.Users {
+		userStates[i] = CDMAUserState{
+			Label:                     user.Label,
+			InputText:                 user.InputText,
+			Seed1_form:                user.Seed1,
+			Seed2_form:                user.Seed2,
+			UseCodeIndex:              user.UseCodeIndex,
+			CodeIndex:                 user.CodeIndex,
+			CodeName:                  user.CodeName,
+			SpreadingFactor:           user.SpreadingFactor,
+			Delay:                     user.Delay,
+			Power:                     user.Power,
+			DataLength:                user.DataBitLength,
+			GeneratedGoldCode:         user.GoldCodeStr,
+			Code:                      user.GoldCode,
+			TransmittedSignalStr:      user.TransmittedSignalStr,
+			ReceivedSignalSegmentStr:  user.ReceivedSignalSegmentStr,
+			CorrelatedSignalStr:       user.CorrelatedSignalStr,
+			DecodedText:               user.DecodedText,
+			ErrorCount:                user.ErrorCount,
+			BER_str:                   fmt.Sprintf("%.2f%%", user.BER*100),
+			MaxOffPeakAutocorrelation: user.MaxOffPeakAutocorrelation,
+			MAI:                       user.MAI,
+		}
+		if user.OriginalDataSeq != nil {
+			userStates[i].OriginalDataStr = user.OriginalDataSeq.String()
+		}
+		if user.EncodedDataSeq != nil {
+			userStates[i].EncodedDataStr = user.EncodedDataSeq.String()
+		}
+		if user.DecodedDataSeq != nil {
+			userStates[i].DecodedDataStr = user.DecodedDataSeq.String()
+		}
 	}
-	data := struct {
-		Timestamp   string
-		UserLabel   string
-		BER_str     string
-		ErrorCount  int
-		TotalBits   int
-		InputText   string
-		DecodedText string
-	}{
-		Timestamp:   cdmaGlobalState.Timestamp,
-		UserLabel:   "A",
-		BER_str:     cdmaGlobalState.BER_A_str,
-		ErrorCount:  cdmaGlobalState.ErrorCountA,
-		TotalBits:   cdmaGlobalState.DataLengthA,
-		InputText:   cdmaGlobalState.InputTextA,
-		DecodedText: cdmaGlobalState.DecodedTextA,
+
+	s.Timestamp = simResult.Timestamp
+	s.GlobalN = simResult.N
+	s.GlobalPoly1 = simResult.Poly1
+	s.GlobalPoly2 = simResult.Poly2
+	s.Family = simResult.Family
+	s.Seed = simResult.Seed
+	s.Users = userStates
+	s.SimulationDataLength = simResult.SimulationDataLength
+	s.FullTransmittedSignalLength = simResult.FullTransmittedSignalLength
+	s.NoiseLevel_form = simResult.NoiseLevel
+	s.CombinedSignalStr = simResult.CombinedSignalStr
+	s.ReceivedSignalStr = simResult.ReceivedSignalStr
+	s.GoldCodeLength = simResult.GoldCodeLength
+	s.AutocorrelationPeak = simResult.AutocorrelationPeak
+	s.CrossCorrelation = simResult.CrossCorrelation
+	s.MaxCrossCorrelation = simResult.MaxCrossCorrelation
+	s.TapsWarnings = codeWarnings(simResult.Family, simResult.N, simResult.Poly1, simResult.Poly2, cdmaResultSeeds(simResult))
+	s.TapsWarnings = append(s.TapsWarnings, orthogonalityWarnings(simResult.Family, userStates)...)
+}
+
+// parseCDMAUserForms collects the repeated per-user transmitter fields of the CDMA form.
+// At least two users are always returned so an empty form still simulates a shared channel.
+func parseCDMAUserForms(r *http.Request) []CDMAUserFormData {
+	texts := r.Form["cdmaUserText"]
+	seeds1 := r.Form["cdmaUserSeed1"]
+	seeds2 := r.Form["cdmaUserSeed2"]
+	codeIndexes := r.Form["cdmaUserCodeIndex"]
+	sfs := r.Form["cdmaUserSF"]
+	delays := r.Form["cdmaUserDelay"]
+	powers := r.Form["cdmaUserPower"]
+
+	numUsers := max(len(texts), len(seeds1), len(seeds2), len(codeIndexes), len(sfs), len(delays), len(powers), 2)
+	numUsers = min(numUsers, cdmaMaxUsers)
+
+	formValueAt := func(values []string, i int) string {
+		if i < len(values) {
+			return values[i]
+		}
+		return ""
 	}
 
-	tmpl, err := template.ParseFiles("templates/cdma_ber_user_result.html")
+	users := make([]CDMAUserFormData, numUsers)
+	for i := range users {
+		users[i] = CDMAUserFormData{
+			TextStr:      formValueAt(texts, i),
+			Seed1Str:     formValueAt(seeds1, i),
+			Seed2Str:     formValueAt(seeds2, i),
+			CodeIndexStr: formValueAt(codeIndexes, i),
+			SFStr:        formValueAt(sfs, i),
+			DelayStr:     formValueAt(delays, i),
+			PowerStr:     formValueAt(powers, i),
+		}
+	}
+	return users
+}
+
+// parseCDMACodeConfig returns the register length and LFSR taps of the CDMA system config module,
+// or the error of negative taps. The taps are not checked against the register length.
+func parseCDMACodeConfig(formData CDMAFormData) (uint, []uint, []uint, error) {
+	goldN := uint(parseIntWithDefault(formData.GoldNStr, 4, 2, 16))
+	taps1, err := parseTapsWithDefault(formData.GoldTaps1Str, []uint{0, 3})
 	if err != nil {
-		http.Error(w, "Template error", http.StatusInternalServerError)
-		return
+		return 0, nil, nil, err
 	}
-	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing CDMABERAResultsHandler template: %v", err)
-		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	taps2, err := parseTapsWithDefault(formData.GoldTaps2Str, []uint{0, 2, 3})
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	return goldN, taps1, taps2, nil
+}
+
+// formTapsError checks the taps of the registers the code family uses with the validation of the
+// API and returns the Polish message of the first invalid register, empty when all taps are valid
+func formTapsError(family string, n uint, taps1, taps2 []uint) string {
+	var errs fieldErrors
+	if simulation.CodeFamilyUsesLFSR1(family) {
+		validateTaps(&errs, "LFSR1", taps1, n)
+	}
+	if simulation.CodeFamilyUsesSeeds(family) {
+		validateTaps(&errs, "LFSR2", taps2, n)
+	}
+	if len(errs) == 0 {
+		return ""
+	}
+	taps := taps1
+	if errs[0].Field == "LFSR2" {
+		taps = taps2
 	}
+	return fmt.Sprintf("%s: odczepy %v są nieprawidłowe, dla rejestru o długości n = %d podaj co najmniej jeden odczep z zakresu od 0 do %d.",
+		errs[0].Field, taps, n, n-1)
 }
 
-func CDMABERBResultsHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
+// cdmaUserConfigs converts the transmitter form fields to simulation user configurations.
+// A filled in code index selects the code from the code set of n-bit registers instead of the seeds.
+// Delays are limited to one period of the longest code, 2^n chips. Texts, seeds and powers are
+// checked with the limits of the API and the first invalid field is returned as a Polish error.
+func cdmaUserConfigs(userForms []CDMAUserFormData, family string, n uint) ([]simulation.CDMAUserConfig, error) {
+	familySize := simulation.CodeFamilySize(family, n)
+	maxChips := 1 << n
+	maxSeed := uint64(1)<<n - 1
+	users := make([]simulation.CDMAUserConfig, len(userForms))
+	for i, userForm := range userForms {
+		users[i] = simulation.CDMAUserConfig{
+			Seed1: parseUint64WithDefault(userForm.Seed1Str, uint64(i)%maxSeed+1),
+			Seed2: parseUint64WithDefault(userForm.Seed2Str, uint64(i)%maxSeed+1),
+			Text:  strings.TrimSpace(userForm.TextStr),
+			Power: parseFloatWithDefault(userForm.PowerStr, 1.0, -math.MaxFloat64, math.MaxFloat64),
+			Delay: parseIntWithDefault(userForm.DelayStr, 0, 0, maxChips),
+		}
+		if family == simulation.CodeFamilyOVSF {
+			users[i].SpreadingFactor = parseIntWithDefault(userForm.SFStr, maxChips, 1, maxChips)
+		}
+		if strings.TrimSpace(userForm.CodeIndexStr) != "" {
+			users[i].UseCodeIndex = true
+			users[i].CodeIndex = parseIntWithDefault(userForm.CodeIndexStr, i%familySize, 0, familySize-1)
+		}
+
+		if length := utf8.RuneCountInString(users[i].Text); length > cdmaMaxTextLength {
+			return nil, fmt.Errorf("Tekst użytkownika %d miałby %d znaków, dozwolone jest najwyżej %d. Skróć tekst.", i+1, length, cdmaMaxTextLength)
+		}
+		if simulation.CodeFamilyUsesSeeds(family) && !users[i].UseCodeIndex {
+			for _, seed := range []uint64{users[i].Seed1, users[i].Seed2} {
+				if seed < 1 || seed > maxSeed {
+					return nil, fmt.Errorf("Ziarno %d użytkownika %d jest nieprawidłowe, dla rejestru o długości n = %d dozwolone są wartości od 1 do %d.", seed, i+1, n, maxSeed)
+				}
+			}
+		}
+		if users[i].Power <= 0 {
+			return nil, fmt.Errorf("Moc użytkownika %d musi być dodatnia.", i+1)
+		}
+	}
+	return users, nil
+}
+
+// cdmaChipCount returns the chips all users of a CDMA simulation send at most: the longest data
+// sequence spread by the longest code of the family, for every user
+func cdmaChipCount(family string, n uint, users []simulation.CDMAUserConfig, randomBits int) int {
+	dataBits := 0
+	for _, user := range users {
+		bits := randomBits
+		if user.Text != "" {
+			bits = 8 * len(user.Text)
+		}
+		dataBits = max(dataBits, bits)
+	}
+	return dataBits * simulation.CodeFamilyLength(family, n) * len(users)
+}
+
+// cdmaUsersForRequest returns the users selected by the optional "user" query parameter
+// (zero-based index), or all users when the parameter is absent. Must be called with the state locked.
+func cdmaUsersForRequest(r *http.Request, state *CDMASimulationState) ([]CDMAUserState, error) {
+	userStr := strings.TrimSpace(r.URL.Query().Get("user"))
+	if userStr == "" {
+		return state.Users, nil
+	}
+	index, err := strconv.Atoi(userStr)
+	if err != nil || index < 0 || index >= len(state.Users) {
+		return nil, fmt.Errorf("nieprawidłowy numer użytkownika: %s", userStr)
+	}
+	return state.Users[index : index+1], nil
+}
+
+// CDMABERResultsHandler returns BER results for every user (or the one selected with ?user=)
+func CDMABERResultsHandler(w http.ResponseWriter, r *http.Request) {
+	state := sessionFor(w, r).CDMA
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	if state.Timestamp == "" {
 		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
 		return
 	}
+	users, err := cdmaUsersForRequest(r, state)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	data := struct {
-		Timestamp   string
-		UserLabel   string
-		BER_str     string
-		ErrorCount  int
-		TotalBits   int
-		InputText   string
-		DecodedText string
-	}{
-		Timestamp:   cdmaGlobalState.Timestamp,
-		UserLabel:   "B",
-		BER_str:     cdmaGlobalState.BER_B_str,
-		ErrorCount:  cdmaGlobalState.ErrorCountB,
-		TotalBits:   cdmaGlobalState.DataLengthB,
-		InputText:   cdmaGlobalState.InputTextB,
-		DecodedText: cdmaGlobalState.DecodedTextB,
+		Timestamp string
+		Users     []CDMABERUserData
+	}{Timestamp: state.Timestamp}
+	for _, user := range users {
+		data.Users = append(data.Users, CDMABERUserData{
+			UserLabel:   user.Label,
+			BER_str:     user.BER_str,
+			ErrorCount:  user.ErrorCount,
+			TotalBits:   user.DataLength,
+			InputText:   user.InputText,
+			DecodedText: user.DecodedText,
+		})
 	}
 
 	tmpl, err := template.ParseFiles("templates/cdma_ber_user_result.html")
@@ -883,31 +1925,34 @@ func CDMABERBResultsHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing CDMABERBResultsHandler template: %v", err)
+		log.Printf("Error executing CDMABERResultsHandler template: %v", err)
 		http.Error(w, "Template execution error", http.StatusInternalServerError)
 	}
 }
 
 // CDMASystemConfigHandler returns system configuration results for CDMA
 func CDMASystemConfigHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
+	state := sessionFor(w, r).CDMA
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	if state.Timestamp == "" {
 		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
 		return
 	}
 
 	data := CDMASystemConfigData{
-		Timestamp:                  cdmaGlobalState.Timestamp,
-		GlobalN:                    cdmaGlobalState.GlobalN,
-		GlobalPoly1:                cdmaGlobalState.GlobalPoly1,
-		GlobalPoly2:                cdmaGlobalState.GlobalPoly2,
-		GeneratedGoldCodeA:         truncateString(cdmaGlobalState.GeneratedGoldCodeA, 64),
-		GeneratedGoldCodeB:         truncateString(cdmaGlobalState.GeneratedGoldCodeB, 64),
-		GoldCodeLength:             cdmaGlobalState.GoldCodeLength,
-		MaxOffPeakAutocorrelationA: cdmaGlobalState.MaxOffPeakAutocorrelationA,
-		MaxOffPeakAutocorrelationB: cdmaGlobalState.MaxOffPeakAutocorrelationB,
-		CrossCorrelationAB:         cdmaGlobalState.CrossCorrelationAB,
+		Timestamp:           state.Timestamp,
+		Seed:                state.Seed,
+		GlobalN:             state.GlobalN,
+		GlobalPoly1:         state.GlobalPoly1,
+		GlobalPoly2:         state.GlobalPoly2,
+		FamilyLabel:         codeFamilyLabel(state.Family),
+		UsesLFSR1:           simulation.CodeFamilyUsesLFSR1(state.Family),
+		UsesLFSR2:           simulation.CodeFamilyUsesSeeds(state.Family),
+		Users:               cdmaCodeData(state.Users),
+		GoldCodeLength:      state.GoldCodeLength,
+		MaxCrossCorrelation: state.MaxCrossCorrelation,
+		TapsWarnings:        state.TapsWarnings,
 	}
 
 	tmpl, err := template.ParseFiles("templates/cdma_system_config_result.html")
@@ -923,20 +1968,22 @@ func CDMASystemConfigHandler(w http.ResponseWriter, r *http.Request) {
 
 // CDMAChannelResultsHandler returns channel results for CDMA
 func CDMAChannelResultsHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
+	state := sessionFor(w, r).CDMA
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	if state.Timestamp == "" {
 		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
 		return
 	}
 
 	data := CDMAChannelData{
-		Timestamp:         cdmaGlobalState.Timestamp,
-		NoiseLevel:        cdmaGlobalState.NoiseLevel_form,
-		CombinedSignalStr: cdmaGlobalState.CombinedSignalStr,
-		ReceivedSignalStr: cdmaGlobalState.ReceivedSignalStr,
-		DataBitLength:     cdmaGlobalState.SimulationDataLength,
-		GoldCodeLength:    cdmaGlobalState.GoldCodeLength,
+		Timestamp:         state.Timestamp,
+		NoiseLevel:        state.NoiseLevel_form,
+		NumUsers:          len(state.Users),
+		CombinedSignalStr: state.CombinedSignalStr,
+		ReceivedSignalStr: state.ReceivedSignalStr,
+		DataBitLength:     state.SimulationDataLength,
+		GoldCodeLength:    state.GoldCodeLength,
 	}
 
 	tmpl, err := template.ParseFiles("templates/cdma_channel_result.html")
@@ -950,188 +1997,391 @@ func CDMAChannelResultsHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// CDMAReceiverAResultsHandler returns receiver results for User A
-func CDMAReceiverAResultsHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
+// CDMAReceiverResultsHandler returns receiver results for every user (or the one selected with ?user=)
+func CDMAReceiverResultsHandler(w http.ResponseWriter, r *http.Request) {
+	state := sessionFor(w, r).CDMA
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	if state.Timestamp == "" {
 		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
 		return
 	}
+	users, err := cdmaUsersForRequest(r, state)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	data := CDMAReceiverUserData{
-		Timestamp:                cdmaGlobalState.Timestamp,
-		UserLabel:                "A",
-		InputText:                cdmaGlobalState.InputTextA,
-		DecodedText:              cdmaGlobalState.DecodedTextA,
-		OriginalDataStr:          cdmaGlobalState.OriginalDataStrA,
-		DecodedDataStr:           cdmaGlobalState.DecodedDataStrA,
-		ErrorCount:               cdmaGlobalState.ErrorCountA,
-		BER_str:                  cdmaGlobalState.BER_A_str,
-		DataLength:               cdmaGlobalState.DataLengthA,
-		ReceivedSignalSegmentStr: cdmaGlobalState.ReceivedSignalSegmentAStr,
-		CorrelatedSignalStr:      cdmaGlobalState.CorrelatedSignalAStr, // NEW
+	data := struct {
+		Timestamp string
+		Users     []CDMAReceiverUserData
+	}{Timestamp: state.Timestamp}
+	for _, user := range users {
+		data.Users = append(data.Users, CDMAReceiverUserData{
+			UserLabel:                user.Label,
+			InputText:                user.InputText,
+			DecodedText:              user.DecodedText,
+			OriginalDataStr:          user.OriginalDataStr,
+			DecodedDataStr:           user.DecodedDataStr,
+			ErrorCount:               user.ErrorCount,
+			BER_str:                  user.BER_str,
+			DataLength:               user.DataLength,
+			ReceivedSignalSegmentStr: user.ReceivedSignalSegmentStr,
+			CorrelatedSignalStr:      user.CorrelatedSignalStr,
+		})
 	}
 
 	tmpl, err := template.ParseFiles("templates/cdma_receiver_user_result.html")
 	if err != nil {
-		log.Printf("CDMAReceiverAResultsHandler: Template error: %v", err)
+		log.Printf("CDMAReceiverResultsHandler: Template error: %v", err)
 		http.Error(w, "Template error", http.StatusInternalServerError)
 		return
 	}
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing CDMAReceiverAResultsHandler template: %v", err)
+		log.Printf("Error executing CDMAReceiverResultsHandler template: %v", err)
 		http.Error(w, "Template execution error", http.StatusInternalServerError)
 	}
 }
 
-// CDMAReceiverBResultsHandler returns receiver results for User B
-func CDMAReceiverBResultsHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
+// CDMATransmitterResultsHandler returns transmitter results for every user (or the one selected with ?user=)
+func CDMATransmitterResultsHandler(w http.ResponseWriter, r *http.Request) {
+	state := sessionFor(w, r).CDMA
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	if state.Timestamp == "" {
 		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
 		return
 	}
+	users, err := cdmaUsersForRequest(r, state)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
-	data := CDMAReceiverUserData{
-		Timestamp:                cdmaGlobalState.Timestamp,
-		UserLabel:                "B",
-		InputText:                cdmaGlobalState.InputTextB,
-		DecodedText:              cdmaGlobalState.DecodedTextB,
-		OriginalDataStr:          cdmaGlobalState.OriginalDataStrB,
-		DecodedDataStr:           cdmaGlobalState.DecodedDataStrB,
-		ErrorCount:               cdmaGlobalState.ErrorCountB,
-		BER_str:                  cdmaGlobalState.BER_B_str,
-		DataLength:               cdmaGlobalState.DataLengthB,
-		ReceivedSignalSegmentStr: cdmaGlobalState.ReceivedSignalSegmentBStr,
-		CorrelatedSignalStr:      cdmaGlobalState.CorrelatedSignalBStr, // NEW
+	data := struct {
+		Timestamp string
+		Users     []CDMATransmitterUserData
+	}{Timestamp: state.Timestamp}
+	for _, user := range users {
+		data.Users = append(data.Users, CDMATransmitterUserData{
+			UserLabel:                   user.Label,
+			InputText:                   user.InputText,
+			Seed1:                       user.Seed1_form,
+			Seed2:                       user.Seed2_form,
+			UseCodeIndex:                user.UseCodeIndex,
+			CodeIndex:                   user.CodeIndex,
+			CodeName:                    user.CodeName,
+			SpreadingFactor:             user.SpreadingFactor,
+			Delay:                       user.Delay,
+			Power:                       user.Power,
+			OriginalDataStr:             user.OriginalDataStr,
+			EncodedDataStr:              user.EncodedDataStr,
+			DataLength:                  user.DataLength,
+			TransmittedSignalStr:        user.TransmittedSignalStr,
+			FullTransmittedSignalLength: state.FullTransmittedSignalLength,
+		})
 	}
 
-	tmpl, err := template.ParseFiles("templates/cdma_receiver_user_result.html")
+	tmpl, err := template.ParseFiles("templates/cdma_transmitter_user_result.html")
 	if err != nil {
-		log.Printf("CDMAReceiverBResultsHandler: Template error: %v", err)
+		log.Printf("CDMATransmitterResultsHandler: Template error: %v", err)
 		http.Error(w, "Template error", http.StatusInternalServerError)
 		return
 	}
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing CDMAReceiverBResultsHandler template: %v", err)
+		log.Printf("Error executing CDMATransmitterResultsHandler template: %v", err)
 		http.Error(w, "Template execution error", http.StatusInternalServerError)
 	}
 }
 
-// CDMATransmitterAResultsHandler returns results for CDMA Transmitter User A
-func CDMATransmitterAResultsHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
+// CDMACodeAnalysisHandler returns code analysis results for CDMA
+func CDMACodeAnalysisHandler(w http.ResponseWriter, r *http.Request) {
+	state := sessionFor(w, r).CDMA
+	state.mutex.RLock()
+	defer state.mutex.RUnlock()
+	if state.Timestamp == "" {
 		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
 		return
 	}
-	data := struct {
-		Timestamp                   string
-		UserLabel                   string
-		InputText                   string
-		Seed1                       uint64
-		Seed2                       uint64
-		OriginalDataStr             string
-		EncodedDataStr              string
-		DataLength                  int
-		TransmittedSignalStr        string
-		FullTransmittedSignalLength int // Added field
-	}{
-		Timestamp:                   cdmaGlobalState.Timestamp,
-		UserLabel:                   "A",
-		InputText:                   cdmaGlobalState.InputTextA,
-		Seed1:                       cdmaGlobalState.SeedA1_form,
-		Seed2:                       cdmaGlobalState.SeedA2_form,
-		OriginalDataStr:             cdmaGlobalState.OriginalDataStrA,
-		EncodedDataStr:              cdmaGlobalState.EncodedDataStrA,
-		DataLength:                  cdmaGlobalState.DataLengthA,
-		TransmittedSignalStr:        cdmaGlobalState.TransmittedSignalAStr,
-		FullTransmittedSignalLength: cdmaGlobalState.FullTransmittedSignalLength, // Populate added field
+
+	labels := make([]string, len(state.Users))
+	for i, user := range state.Users {
+		labels[i] = user.Label
 	}
-	tmpl, err := template.ParseFiles("templates/cdma_transmitter_user_result.html")
+
+	data := CDMACodeAnalysisData{
+		Timestamp:           state.Timestamp,
+		AutocorrelationPeak: state.AutocorrelationPeak,
+		Users:               cdmaCodeData(state.Users),
+		UserLabels:          labels,
+		CrossCorrelation:    state.CrossCorrelation,
+		MaxCrossCorrelation: state.MaxCrossCorrelation,
+		GoldCodeLength:      state.GoldCodeLength,
+	}
+	if len(state.Users) > 0 && state.Users[0].Code != nil {
+		data.CodeA = parseIntWithDefault(r.URL.Query().Get("codeA"), 0, 0, len(state.Users)-1)
+		data.CodeB = parseIntWithDefault(r.URL.Query().Get("codeB"), min(1, len(state.Users)-1), 0, len(state.Users)-1)
+		setCorrelationFunctions(&data, state)
+	}
+
+	tmpl, err := template.ParseFiles("templates/cdma_code_analysis_result.html", "templates/chart.html")
 	if err != nil {
-		log.Printf("CDMATransmitterAResultsHandler: Template error: %v", err)
+		log.Printf("CDMACodeAnalysisHandler: Template error: %v", err)
 		http.Error(w, "Template error", http.StatusInternalServerError)
 		return
 	}
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing CDMATransmitterAResultsHandler template: %v", err)
+		log.Printf("Error executing CDMACodeAnalysisHandler template: %v", err)
 		http.Error(w, "Template execution error", http.StatusInternalServerError)
 	}
 }
 
-// CDMATransmitterBResultsHandler returns results for CDMA Transmitter User B
-func CDMATransmitterBResultsHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
-		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
+// maxCorrelationPoints limits the points of every correlation function drawn in the charts
+const maxCorrelationPoints = 400
+
+// setCorrelationFunctions fills the charts and histograms of the codes data.CodeA and data.CodeB.
+// The caller must hold state.mutex.
+func setCorrelationFunctions(data *CDMACodeAnalysisData, state *CDMASimulationState) {
+	codeA, codeB := state.Users[data.CodeA].Code, state.Users[data.CodeB].Code
+	labelA, labelB := state.Users[data.CodeA].Label, state.Users[data.CodeB].Label
+	auto := simulation.NewCorrelationFunctions(codeA, codeA)
+	cross := simulation.NewCorrelationFunctions(codeA, codeB)
+
+	autoLabel := "Autokorelacja " + labelA
+	crossLabel := fmt.Sprintf("Korelacja wzajemna %s, %s", labelA, labelB)
+	data.PeriodicChart = buildLineChart([]ChartSeries{
+		correlationSeries(autoLabel, 0, auto.Normalize(auto.Periodic)),
+		correlationSeries(crossLabel, 0, cross.Normalize(cross.Periodic)),
+	}, "τ [chipy]", "θ(τ)", false)
+	data.AperiodicChart = buildLineChart([]ChartSeries{
+		correlationSeries(autoLabel, -(auto.Length - 1), auto.Normalize(auto.Aperiodic)),
+		correlationSeries(crossLabel, -(cross.Length - 1), cross.Normalize(cross.Aperiodic)),
+	}, "τ [chipy]", "C(τ)", false)
+	data.OddChart = buildLineChart([]ChartSeries{
+		correlationSeries("Parzysta "+labelA+", "+labelB, 0, cross.Normalize(cross.Periodic)),
+		correlationSeries("Nieparzysta "+labelA+", "+labelB, 0, cross.Normalize(cross.Odd)),
+	}, "τ [chipy]", "θ(τ)", false)
+
+	autoSpectrum := simulation.CorrelationHistogram(auto.Periodic[1:])
+	crossSpectrum := simulation.CorrelationHistogram(cross.Periodic)
+	data.AutoHistogram = correlationHistogramRows(autoSpectrum, auto.Length)
+	data.CrossHistogram = correlationHistogramRows(crossSpectrum, cross.Length)
+
+	if state.Family == simulation.CodeFamilyGold && auto.Length == cross.Length {
+		data.GoldCheck = true
+		data.GoldValues = simulation.PreferredPairValues(state.GlobalN)
+		data.AutoThreeValued = simulation.ThreeValued(state.GlobalN, autoSpectrum)
+		data.CrossThreeValued = simulation.ThreeValued(state.GlobalN, crossSpectrum)
+	}
+}
+
+// correlationSeries turns a correlation function starting at shift first into a chart line of at most
+// maxCorrelationPoints points, keeping the value of the largest magnitude of every merged range of shifts
+func correlationSeries(label string, first int, values []float64) ChartSeries {
+	step := max(1, (len(values)+maxCorrelationPoints-1)/maxCorrelationPoints)
+	series := ChartSeries{Label: label}
+	for start := 0; start < len(values); start += step {
+		peak := start
+		for i := start + 1; i < min(start+step, len(values)); i++ {
+			if math.Abs(values[i]) > math.Abs(values[peak]) {
+				peak = i
+			}
+		}
+		series.X = append(series.X, float64(first+peak))
+		series.Y = append(series.Y, values[peak])
+	}
+	return series
+}
+
+// correlationHistogramRows formats a correlation spectrum of codes of the given length
+func correlationHistogramRows(spectrum []simulation.CorrelationCount, length int) []CorrelationHistogramRow {
+	rows := make([]CorrelationHistogramRow, len(spectrum))
+	for i, c := range spectrum {
+		rows[i] = CorrelationHistogramRow{
+			Value:      c.Value,
+			Normalized: fmt.Sprintf("%.4f", float64(c.Value)/float64(length)),
+			Count:      c.Count,
+		}
+	}
+	return rows
+}
+
+// Helper building the per-user code summary shown in modules 1 and 6
+func cdmaCodeData(users []CDMAUserState) []CDMACodeData {
+	codes := make([]CDMACodeData, len(users))
+	for i, user := range users {
+		codes[i] = CDMACodeData{
+			UserLabel:                 user.Label,
+			GeneratedGoldCode:         truncateString(user.GeneratedGoldCode, 64),
+			CodeName:                  user.CodeName,
+			SpreadingFactor:           user.SpreadingFactor,
+			Delay:                     user.Delay,
+			MaxOffPeakAutocorrelation: user.MaxOffPeakAutocorrelation,
+			MAI:                       user.MAI,
+		}
+	}
+	return codes
+}
+
+// CDMASweepHandler runs a Monte Carlo BER-vs-noise sweep over the CDMA link configured in the form
+func CDMASweepHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		log.Printf("CDMA sweep form parse error: %v", err)
+		http.Error(w, "Form parse error", http.StatusBadRequest)
 		return
 	}
-	data := struct {
-		Timestamp                   string
-		UserLabel                   string
-		InputText                   string
-		Seed1                       uint64
-		Seed2                       uint64
-		OriginalDataStr             string
-		EncodedDataStr              string
-		DataLength                  int
-		TransmittedSignalStr        string
-		FullTransmittedSignalLength int // Added field
-	}{
-		Timestamp:                   cdmaGlobalState.Timestamp,
-		UserLabel:                   "B",
-		InputText:                   cdmaGlobalState.InputTextB,
-		Seed1:                       cdmaGlobalState.SeedB1_form,
-		Seed2:                       cdmaGlobalState.SeedB2_form,
-		OriginalDataStr:             cdmaGlobalState.OriginalDataStrB,
-		EncodedDataStr:              cdmaGlobalState.EncodedDataStrB,
-		DataLength:                  cdmaGlobalState.DataLengthB,
-		TransmittedSignalStr:        cdmaGlobalState.TransmittedSignalBStr,
-		FullTransmittedSignalLength: cdmaGlobalState.FullTransmittedSignalLength, // Populate added field
+
+	formData := CDMAFormData{
+		GoldNStr:     r.FormValue("cdmaGoldN"),
+		GoldTaps1Str: r.FormValue("cdmaGoldTaps1"),
+		GoldTaps2Str: r.FormValue("cdmaGoldTaps2"),
+		FamilyStr:    r.FormValue("cdmaCodeFamily"),
+		Users:        parseCDMAUserForms(r),
 	}
-	tmpl, err := template.ParseFiles("templates/cdma_transmitter_user_result.html")
+	goldN, taps1, taps2, err := parseCDMACodeConfig(formData)
 	if err != nil {
-		log.Printf("CDMATransmitterBResultsHandler: Template error: %v", err)
-		http.Error(w, "Template error", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing CDMATransmitterBResultsHandler template: %v", err)
-		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	family := parseCodeFamily(formData.FamilyStr)
+	if !simulation.CodeFamilySupported(family, goldN) {
+		http.Error(w, codeFamilyError(family, goldN), http.StatusBadRequest)
+		return
+	}
+	if message := formTapsError(family, goldN, taps1, taps2); message != "" {
+		http.Error(w, message, http.StatusBadRequest)
+		return
+	}
+	users, err := cdmaUserConfigs(formData.Users, family, goldN)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	config := simulation.SweepConfig{
+		N:            goldN,
+		Poly1:        taps1,
+		Poly2:        taps2,
+		Family:       family,
+		Users:        users,
+		Mode:         simulation.SweepModeEbN0,
+		Start:        parseFloatWithDefault(r.FormValue("cdmaSweepStart"), 0, -50, 1000),
+		Stop:         parseFloatWithDefault(r.FormValue("cdmaSweepStop"), 10, -50, 1000),
+		Step:         parseFloatWithDefault(r.FormValue("cdmaSweepStep"), 1, 0.01, 1000),
+		BitsPerTrial: parseIntWithDefault(r.FormValue("cdmaSweepBitsPerTrial"), 100, 1, 10000),
+		TargetErrors: parseIntWithDefault(r.FormValue("cdmaSweepTargetErrors"), 100, 0, 1000000),
+		MaxBits:      parseIntWithDefault(r.FormValue("cdmaSweepMaxBits"), 100000, 1, cdmaSweepMaxBits),
+		Workers:      parseIntWithDefault(r.FormValue("cdmaSweepWorkers"), runtime.NumCPU(), 1, cdmaSweepMaxWorkers),
+		Seed:         parseSeedWithDefault(r.FormValue("cdmaSeed")),
+	}
+	if r.FormValue("cdmaSweepMode") == simulation.SweepModeSigma {
+		// The noise axis uses the same percent scale as the channel module
+		config.Mode = simulation.SweepModeSigma
+		config.Start /= 100
+		config.Stop /= 100
+		config.Step /= 100
+		if config.Start < 0 {
+			config.Start = 0
+		}
+	}
+	if len(config.Values()) > cdmaSweepMaxPoints {
+		http.Error(w, fmt.Sprintf("Zbyt wiele punktów pomiarowych (maks. %d).", cdmaSweepMaxPoints), http.StatusBadRequest)
+		return
+	}
+	if chips := config.MaxChips(); chips > cdmaSweepMaxChips {
+		http.Error(w, fmt.Sprintf("Analiza BER wysłałaby do %d chipów, dozwolone jest najwyżej %d. Zmniejsz liczbę punktów, maksymalną liczbę bitów lub liczbę użytkowników albo wybierz krótsze kody.", chips, cdmaSweepMaxChips), http.StatusBadRequest)
+		return
 	}
+
+	result, err := simulation.SweepCDMABER(r.Context(), config)
+	if err != nil {
+		log.Printf("CDMA sweep stopped: %v", err)
+		return
+	}
+
+	sweepState := sessionFor(w, r).Sweep
+	sweepState.mutex.Lock()
+	sweepState.Result = result
+	sweepState.mutex.Unlock()
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("HX-Trigger", "cdma-sweep-complete")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `<div class="success-message">Analiza BER (Monte Carlo) zakończona! Czas: %s</div>`, time.Now().Format("15:04:05"))
+	seeds := make([][2]uint64, len(config.Users))
+	for i, user := range config.Users {
+		seeds[i] = codeSeeds(user.UseCodeIndex, user.Seed1, user.Seed2)
+	}
+	writeWarnings(w, codeWarnings(family, goldN, taps1, taps2, seeds))
 }
 
-// CDMACodeAnalysisHandler returns code analysis results for CDMA
-func CDMACodeAnalysisHandler(w http.ResponseWriter, r *http.Request) {
-	cdmaGlobalState.mutex.RLock()
-	defer cdmaGlobalState.mutex.RUnlock()
-	if cdmaGlobalState.Timestamp == "" {
-		http.Error(w, "Uruchom symulację CDMA.", http.StatusBadRequest)
+// CDMASweepResultsHandler renders the table and chart of the latest BER sweep
+func CDMASweepResultsHandler(w http.ResponseWriter, r *http.Request) {
+	sweepState := sessionFor(w, r).Sweep
+	sweepState.mutex.RLock()
+	result := sweepState.Result
+	sweepState.mutex.RUnlock()
+	if result == nil {
+		http.Error(w, "Uruchom analizę BER (Monte Carlo).", http.StatusBadRequest)
 		return
 	}
 
-	data := CDMACodeAnalysisData{
-		Timestamp:                  cdmaGlobalState.Timestamp,
-		AutocorrelationPeak:        cdmaGlobalState.AutocorrelationPeak,
-		MaxOffPeakAutocorrelationA: cdmaGlobalState.MaxOffPeakAutocorrelationA,
-		MaxOffPeakAutocorrelationB: cdmaGlobalState.MaxOffPeakAutocorrelationB,
-		CrossCorrelationAB:         cdmaGlobalState.CrossCorrelationAB,
-		GoldCodeLength:             cdmaGlobalState.GoldCodeLength,
+	xLabel := "Eb/N0 [dB]"
+	if result.Config.Mode == simulation.SweepModeSigma {
+		xLabel = "Poziom szumu [%]"
+	}
+
+	data := CDMASweepData{
+		Timestamp:    result.Timestamp,
+		XLabel:       xLabel,
+		UserLabels:   result.UserLabels,
+		CodeLength:   result.CodeLength,
+		BitsPerTrial: result.Config.BitsPerTrial,
+		TargetErrors: result.Config.TargetErrors,
+		MaxBits:      result.Config.MaxBits,
+		Workers:      result.Config.Workers,
+		Seed:         result.Config.Seed,
+	}
+	for _, point := range result.Points {
+		row := CDMASweepRow{
+			Value:      fmt.Sprintf("%.2f", point.Value),
+			EbN0dB:     fmt.Sprintf("%.2f", point.EbN0dB),
+			NoiseSigma: fmt.Sprintf("%.1f%%", point.NoiseSigma*100),
+			Trials:     point.Trials,
+		}
+		if result.Config.Mode == simulation.SweepModeSigma {
+			row.Value = fmt.Sprintf("%.1f", point.Value*100)
+		}
+		for _, user := range point.Users {
+			row.Users = append(row.Users, CDMASweepCell{
+				BER_str: fmt.Sprintf("%.2e", user.BER),
+				Errors:  user.Errors,
+				Bits:    user.Bits,
+			})
+		}
+		data.Rows = append(data.Rows, row)
+	}
+
+	series := make([]ChartSeries, len(result.UserLabels))
+	for u, label := range result.UserLabels {
+		xs, bers := result.Curve(u)
+		if result.Config.Mode == simulation.SweepModeSigma {
+			for i := range xs {
+				xs[i] *= 100
+			}
+		}
+		series[u] = ChartSeries{Label: "Użytkownik " + label, X: xs, Y: bers}
 	}
+	data.Chart = buildLineChart(series, xLabel, "BER", true)
 
-	tmpl, err := template.ParseFiles("templates/cdma_code_analysis_result.html")
+	tmpl, err := template.ParseFiles("templates/cdma_ber_sweep_result.html", "templates/chart.html")
 	if err != nil {
-		log.Printf("CDMACodeAnalysisHandler: Template error: %v", err)
+		log.Printf("CDMASweepResultsHandler: Template error: %v", err)
 		http.Error(w, "Template error", http.StatusInternalServerError)
 		return
 	}
 	if err := tmpl.Execute(w, data); err != nil {
-		log.Printf("Error executing CDMACodeAnalysisHandler template: %v", err)
+		log.Printf("Error executing CDMASweepResultsHandler template: %v", err)
 		http.Error(w, "Template execution error", http.StatusInternalServerError)
 	}
 }
@@ -1147,20 +2397,183 @@ func truncateString(s string, maxLength int) string {
 	return s[:maxLength-3] + "..."
 }
 
-// Helper function to parse comma-separated taps
-func parseTaps(tapsStr string) []uint {
+// tapsWarnings describes the gold code registers whose taps do not give a maximal-length sequence,
+// together with the periods actually obtained from the given (seed1, seed2) pairs.
+// When both registers are maximal-length it checks that they form a preferred pair instead.
+func tapsWarnings(n uint, taps1, taps2 []uint, seeds [][2]uint64) []string {
+	var warnings []string
+	for reg, taps := range [][]uint{taps1, taps2} {
+		if warning, ok := registerWarning(reg, n, taps, seeds); ok {
+			warnings = append(warnings, warning)
+		}
+	}
+	if len(warnings) > 0 {
+		return warnings
+	}
+
+	if n%4 == 0 {
+		return []string{fmt.Sprintf("Dla n = %d (podzielnego przez 4) nie istnieją pary preferowane, kody nie są prawdziwymi kodami Golda.", n)}
+	}
+	poly1, poly2 := simulation.TapsToPolynomial(n, taps1), simulation.TapsToPolynomial(n, taps2)
+	if poly1 == poly2 {
+		return []string{fmt.Sprintf("Oba rejestry używają wielomianu %s, kod Golda wymaga dwóch różnych m-sekwencji.", poly1)}
+	}
+	preferred, spectrum := simulation.IsPreferredPair(poly1, poly2)
+	if !preferred {
+		values := make([]string, len(spectrum))
+		for i, c := range spectrum {
+			values[i] = strconv.Itoa(c.Value)
+		}
+		warnings = append(warnings, fmt.Sprintf(
+			"Odczepy %v i %v nie tworzą pary preferowanej: korelacja wzajemna m-sekwencji przyjmuje wartości {%s} zamiast %v.",
+			taps1, taps2, strings.Join(values, ", "), simulation.PreferredPairValues(n)))
+	}
+	return warnings
+}
+
+// registerWarning describes LFSR reg+1 if its taps do not give a maximal-length sequence
+func registerWarning(reg int, n uint, taps []uint, seeds [][2]uint64) (string, bool) {
+	check := simulation.CheckTaps(n, taps, seeds[0][reg])
+	if check.Primitive {
+		return "", false
+	}
+	var periods []string
+	seen := make(map[int]bool)
+	for _, pair := range seeds {
+		period := simulation.LFSRPeriod(n, taps, pair[reg])
+		if !seen[period] {
+			seen[period] = true
+			periods = append(periods, strconv.Itoa(period))
+		}
+	}
+	return fmt.Sprintf(
+		"LFSR%d: odczepy %v odpowiadają wielomianowi %s, który nie jest pierwotny. Uzyskany okres: %s zamiast %d. Przykładowe odczepy dające m-sekwencję: %v.",
+		reg+1, taps, check.Polynomial, strings.Join(periods, ", "), check.MaxPeriod, simulation.PrimitiveTaps(n)), true
+}
+
+// codeWarnings checks the registers of the code family used by len(seeds) users. Families without
+// seeds only use LFSR1, which must give an m-sequence, or no register at all. Families with
+// a fixed number of codes have too few codes for more users than their size.
+func codeWarnings(family string, n uint, taps1, taps2 []uint, seeds [][2]uint64) []string {
+	if simulation.CodeFamilyUsesSeeds(family) {
+		return tapsWarnings(n, taps1, taps2, seeds)
+	}
+	var warnings []string
+	if simulation.CodeFamilyUsesLFSR1(family) {
+		if warning, ok := registerWarning(0, n, taps1, [][2]uint64{{1, 1}}); ok {
+			warnings = append(warnings, warning)
+		}
+	}
+	if size := simulation.CodeFamilySize(family, n); len(seeds) > size {
+		warnings = append(warnings, fmt.Sprintf(
+			"Liczba kodów w rodzinie (%s) dla n = %d: %d, mniej niż użytkowników (%d) - część użytkowników używa tego samego kodu.",
+			codeFamilyLabel(family), n, size, len(seeds)))
+	}
+	return warnings
+}
+
+// orthogonalityWarnings reports the users of an orthogonal code family that still see interference
+// from the others, because of their delays or because the OVSF tree ran out of free codes
+func orthogonalityWarnings(family string, users []CDMAUserState) []string {
+	if !simulation.CodeFamilyOrthogonal(family) {
+		return nil
+	}
+	var warnings []string
+	for _, user := range users {
+		if user.MAI > 1e-6 {
+			warnings = append(warnings, fmt.Sprintf(
+				"Użytkownik %s: interferencja wielodostępna %.4f mimo kodów ortogonalnych - ortogonalność niszczą opóźnienia lub brak wolnego kodu.",
+				user.Label, user.MAI))
+		}
+	}
+	return warnings
+}
+
+// parseCodeFamily returns the code family selected in a form, gold codes for unknown values
+func parseCodeFamily(familyStr string) string {
+	switch family := strings.TrimSpace(familyStr); family {
+	case simulation.CodeFamilyKasamiSmall, simulation.CodeFamilyKasamiLarge, simulation.CodeFamilyWalsh, simulation.CodeFamilyOVSF,
+		simulation.CodeFamilyMSequence, simulation.CodeFamilyBarker, simulation.CodeFamilyJPL:
+		return family
+	}
+	return simulation.CodeFamilyGold
+}
+
+// codeFamilyLabel returns the display name of a code family
+func codeFamilyLabel(family string) string {
+	switch family {
+	case simulation.CodeFamilyKasamiSmall:
+		return "mały zbiór Kasamiego"
+	case simulation.CodeFamilyKasamiLarge:
+		return "duży zbiór Kasamiego"
+	case simulation.CodeFamilyWalsh:
+		return "kody Walsha-Hadamarda"
+	case simulation.CodeFamilyOVSF:
+		return "kody OVSF"
+	case simulation.CodeFamilyMSequence:
+		return "przesunięcia m-sekwencji"
+	case simulation.CodeFamilyBarker:
+		return "przesunięcia kodu Barkera"
+	case simulation.CodeFamilyJPL:
+		return "przesunięcia kodu JPL"
+	}
+	return "kody Golda"
+}
+
+// codeFamilyError explains why the code family cannot be built from n-bit registers
+func codeFamilyError(family string, n uint) string {
+	if family == simulation.CodeFamilyJPL {
+		return fmt.Sprintf("Kody JPL są dostępne dla n od 2 do 12, podano n = %d.", n)
+	}
+	if family == simulation.CodeFamilyKasamiLarge {
+		return fmt.Sprintf("Duży zbiór Kasamiego wymaga n dającego resztę 2 z dzielenia przez 4 (np. 6, 10, 14), podano n = %d.", n)
+	}
+	return fmt.Sprintf("Zbiór Kasamiego wymaga parzystej długości rejestru, podano n = %d.", n)
+}
+
+// cdmaResultSeeds returns the (seed1, seed2) pairs of all users of a CDMA result
+func cdmaResultSeeds(result *simulation.CDMAResult) [][2]uint64 {
+	seeds := make([][2]uint64, len(result.Users))
+	for i, user := range result.Users {
+		seeds[i] = codeSeeds(user.UseCodeIndex, user.Seed1, user.Seed2)
+	}
+	return seeds
+}
+
+// codeSeeds returns the register states a user's code starts from. Gold family codes
+// always start both registers from state 1.
+func codeSeeds(useCodeIndex bool, seed1, seed2 uint64) [2]uint64 {
+	if useCodeIndex {
+		return [2]uint64{1, 1}
+	}
+	return [2]uint64{seed1, seed2}
+}
+
+// writeWarnings appends warnings to an HTML status response
+func writeWarnings(w http.ResponseWriter, warnings []string) {
+	for _, warning := range warnings {
+		fmt.Fprintf(w, `<div class="warning-message">%s</div>`, template.HTMLEscapeString(warning))
+	}
+}
+
+// Helper function to parse comma-separated taps, entries that are not numbers are skipped
+// and negative taps are rejected
+func parseTaps(tapsStr string) ([]uint, error) {
 	tapsStr = strings.TrimSpace(tapsStr)
 	if tapsStr == "" {
-		return nil
+		return nil, nil
 	}
 	parts := strings.Split(tapsStr, ",")
 	taps := make([]uint, 0, len(parts))
 	for _, p := range parts {
 		if val, err := strconv.Atoi(strings.TrimSpace(p)); err == nil {
+			if val < 0 {
+				return nil, fmt.Errorf("Odczep %d jest ujemny, pozycje odczepów liczone są od 0.", val)
+			}
 			taps = append(taps, uint(val))
 		}
 	}
-	return taps
+	return taps, nil
 }
 
 func bitsToASCII(bits string) string {
@@ -1211,14 +2624,26 @@ func parseFloatWithDefault(valStr string, defaultVal float64, minVal float64, ma
 	return defaultVal
 }
 
-func parseTapsWithDefault(tapsStr string, defaultTaps []uint) []uint {
+// parseSeedWithDefault returns the master seed given in the form, or a fresh time-based
+// seed when the field is empty or invalid. The seed is stored with the results so the run can be repeated.
+func parseSeedWithDefault(valStr string) int64 {
+	if val, err := strconv.ParseInt(strings.TrimSpace(valStr), 10, 64); err == nil {
+		return val
+	}
+	return time.Now().UnixNano()
+}
+
+func parseTapsWithDefault(tapsStr string, defaultTaps []uint) ([]uint, error) {
 	trimmedTapsStr := strings.TrimSpace(tapsStr)
 	if trimmedTapsStr == "" {
-		return defaultTaps
+		return defaultTaps, nil
+	}
+	parsed, err := parseTaps(trimmedTapsStr)
+	if err != nil {
+		return nil, err
 	}
-	parsed := parseTaps(trimmedTapsStr)
 	if len(parsed) == 0 {
-		return defaultTaps
+		return defaultTaps, nil
 	}
-	return parsed
+	return parsed, nil
 }
@@ -0,0 +1,187 @@
+package src
+
+import (
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// RunHistoryData holds data for the run history template
+type RunHistoryData struct {
+	Runs       []RunHistoryRow
+	MaxRuns    int
+	MaxAgeDays int
+}
+
+// RunHistoryRow is a single run in the history list
+type RunHistoryRow struct {
+	ID        string
+	Kind      string
+	CreatedAt string
+	N         int
+	Taps      string
+	BER_str   string
+	Channel   string // Error rate (general) or noise level (CDMA)
+	NumUsers  int
+	InputText string
+	Seed      int64
+	IsCDMA    bool
+}
+
+// HistoryHandler lists the stored runs with their key metrics
+func HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	records, err := runHistory.List(sessionFor(w, r).ID)
+	if err != nil {
+		log.Printf("HistoryHandler: %v", err)
+		http.Error(w, "Nie udało się odczytać historii symulacji.", http.StatusInternalServerError)
+		return
+	}
+
+	data := RunHistoryData{
+		MaxRuns:    runHistory.maxRuns,
+		MaxAgeDays: int(runHistory.maxAge / (24 * time.Hour)),
+	}
+	for _, record := range records {
+		row := RunHistoryRow{
+			ID:        record.ID,
+			Kind:      "Ogólna",
+			CreatedAt: record.CreatedAt.Format("2006-01-02 15:04:05"),
+			N:         record.Summary.N,
+			Taps:      fmt.Sprintf("%v / %v", record.Summary.Taps1, record.Summary.Taps2),
+			BER_str:   fmt.Sprintf("%.2f%%", record.Summary.BER*100),
+			Channel:   fmt.Sprintf("błędy %.1f%%", record.Summary.ErrorRate),
+			InputText: record.Summary.InputText,
+			Seed:      record.Summary.Seed,
+		}
+		if record.Kind == RunKindCDMA {
+			row.Kind = "CDMA"
+			row.IsCDMA = true
+			row.NumUsers = record.Summary.NumUsers
+			row.Channel = fmt.Sprintf("szum %.1f%%", record.Summary.NoiseLevel)
+		}
+		data.Runs = append(data.Runs, row)
+	}
+
+	tmpl, err := template.ParseFiles("templates/run_history.html")
+	if err != nil {
+		log.Printf("HistoryHandler: Template error: %v", err)
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("Error executing HistoryHandler template: %v", err)
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	}
+}
+
+// HistoryReopenHandler loads a stored run into the session and refreshes the module views
+func HistoryReopenHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	session := sessionFor(w, r)
+	record, err := runHistory.Load(strings.TrimSpace(r.URL.Query().Get("id")), session.ID)
+	if err != nil {
+		log.Printf("HistoryReopenHandler: %v", err)
+		http.Error(w, "Nie znaleziono zapisanej symulacji.", http.StatusNotFound)
+		return
+	}
+
+	trigger := ""
+	switch {
+	case record.Kind == RunKindGeneral && record.General != nil:
+		session.General.mutex.Lock()
+		session.General.setFrom(record.General)
+		session.General.mutex.Unlock()
+		trigger = "simulation-complete"
+	case record.Kind == RunKindCDMA && record.CDMA != nil:
+		session.CDMA.mutex.Lock()
+		session.CDMA.setFromResult(record.CDMA)
+		session.CDMA.mutex.Unlock()
+		trigger = "cdma-simulation-complete"
+	default:
+		http.Error(w, "Zapisana symulacja jest uszkodzona.", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("HX-Trigger", trigger)
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, `<div class="success-message">Wczytano symulację z %s.</div>`, record.CreatedAt.Format("2006-01-02 15:04:05"))
+}
+
+// HistoryDeleteHandler removes a stored run
+func HistoryDeleteHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if err := runHistory.Delete(strings.TrimSpace(r.URL.Query().Get("id")), sessionFor(w, r).ID); err != nil {
+		log.Printf("HistoryDeleteHandler: %v", err)
+		http.Error(w, "Nie udało się usunąć symulacji.", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.Header().Set("HX-Trigger", "history-changed")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, `<div class="success-message">Usunięto symulację z historii.</div>`)
+}
+
+// HistoryCompareHandler renders the side-by-side comparison of the runs given with ?id=
+func HistoryCompareHandler(w http.ResponseWriter, r *http.Request) {
+	ids := r.URL.Query()["id"]
+	comparison, err := loadComparison(ids, sessionFor(w, r).ID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data := struct {
+		*RunComparison
+		Columns int
+		JSONURL template.URL
+	}{comparison, len(comparison.Runs) + 1, template.URL("/api/v1/history/compare?" + r.URL.Query().Encode())}
+
+	tmpl, err := template.ParseFiles("templates/run_compare.html")
+	if err != nil {
+		log.Printf("HistoryCompareHandler: Template error: %v", err)
+		http.Error(w, "Template error", http.StatusInternalServerError)
+		return
+	}
+	if err := tmpl.Execute(w, data); err != nil {
+		log.Printf("Error executing HistoryCompareHandler template: %v", err)
+		http.Error(w, "Template execution error", http.StatusInternalServerError)
+	}
+}
+
+// APIHistoryCompareHandler returns the comparison of the runs given with ?id= as JSON
+func APIHistoryCompareHandler(w http.ResponseWriter, r *http.Request) {
+	comparison, err := loadComparison(r.URL.Query()["id"], sessionFor(w, r).ID)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, APIError{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, comparison)
+}
+
+// loadComparison loads the runs of the session with the given IDs and compares them
+func loadComparison(ids []string, sessionID string) (*RunComparison, error) {
+	if len(ids) < compareMinRuns || len(ids) > compareMaxRuns {
+		return nil, fmt.Errorf("wybierz od %d do %d symulacji do porównania", compareMinRuns, compareMaxRuns)
+	}
+	records := make([]*RunRecord, len(ids))
+	for i, id := range ids {
+		record, err := runHistory.Load(strings.TrimSpace(id), sessionID)
+		if err != nil {
+			log.Printf("loadComparison: %v", err)
+			return nil, fmt.Errorf("nie znaleziono zapisanej symulacji %s", id)
+		}
+		records[i] = record
+	}
+	return compareRuns(records), nil
+}
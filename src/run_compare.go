@@ -0,0 +1,278 @@
+package src
+
+import (
+	"fmt"
+
+	"github.com/BartiX259/BSO_Projekt/src/simulation"
+)
+
+// Number of runs accepted by a single comparison
+const (
+	compareMinRuns = 2
+	compareMaxRuns = 6
+)
+
+// missingValue marks a parameter or metric the run does not have (e.g. a CDMA user of a general run)
+const missingValue = "—"
+
+// RunComparison lines up the parameters and metrics of several stored runs.
+// The first run is the baseline the other runs are compared against.
+type RunComparison struct {
+	Runs []ComparedRun   `json:"runs"`
+	Rows []ComparisonRow `json:"rows"`
+}
+
+// ComparedRun identifies one compared run
+type ComparedRun struct {
+	ID        string `json:"id"`
+	Kind      string `json:"kind"`
+	CreatedAt string `json:"createdAt"`
+}
+
+// ComparisonRow is a single parameter or metric of every compared run
+type ComparisonRow struct {
+	Section string           `json:"section"`
+	Label   string           `json:"label"`
+	Cells   []ComparisonCell `json:"values"`
+	Differs bool             `json:"differs"` // At least one run differs from the baseline
+}
+
+// ComparisonCell is the formatted value of one run
+type ComparisonCell struct {
+	Value   string `json:"value"`
+	Differs bool   `json:"differs"` // Value differs from the baseline run
+}
+
+// compareRuns builds the comparison of the given runs, keeping their order
+func compareRuns(records []*RunRecord) *RunComparison {
+	comparison := &RunComparison{}
+	for _, record := range records {
+		comparison.Runs = append(comparison.Runs, ComparedRun{
+			ID:        record.ID,
+			Kind:      record.Kind,
+			CreatedAt: record.CreatedAt.Format("2006-01-02 15:04:05"),
+		})
+	}
+
+	// Each row is filled by a function returning the value of a single run, or false if it has none
+	addRow := func(section, label string, value func(record *RunRecord) (string, bool)) {
+		row := ComparisonRow{Section: section, Label: label}
+		present := false
+		for _, record := range records {
+			cell := ComparisonCell{Value: missingValue}
+			if v, ok := value(record); ok {
+				cell.Value = v
+				present = true
+			}
+			row.Cells = append(row.Cells, cell)
+		}
+		if !present {
+			return
+		}
+		for i := 1; i < len(row.Cells); i++ {
+			if row.Cells[i].Value != row.Cells[0].Value {
+				row.Cells[i].Differs = true
+				row.Differs = true
+			}
+		}
+		comparison.Rows = append(comparison.Rows, row)
+	}
+	general := func(f func(g *SimulationResults) string) func(*RunRecord) (string, bool) {
+		return func(record *RunRecord) (string, bool) {
+			if record.General == nil {
+				return "", false
+			}
+			return f(record.General), true
+		}
+	}
+	cdma := func(f func(c *simulation.CDMAResult) string) func(*RunRecord) (string, bool) {
+		return func(record *RunRecord) (string, bool) {
+			if record.CDMA == nil {
+				return "", false
+			}
+			return f(record.CDMA), true
+		}
+	}
+
+	// Parameters shared by both kinds of runs
+	addRow("Parametry", "Typ", func(record *RunRecord) (string, bool) { return record.Kind, true })
+	addRow("Parametry", "N", func(record *RunRecord) (string, bool) { return fmt.Sprint(record.Summary.N), true })
+	addRow("Parametry", "Odczepy LFSR 1", func(record *RunRecord) (string, bool) { return fmt.Sprint(record.Summary.Taps1), true })
+	addRow("Parametry", "Odczepy LFSR 2", func(record *RunRecord) (string, bool) { return fmt.Sprint(record.Summary.Taps2), true })
+	addRow("Parametry", "Rodzina kodów", func(record *RunRecord) (string, bool) { return runCodeFamily(record), true })
+	addRow("Parametry", "Ziarno", func(record *RunRecord) (string, bool) { return fmt.Sprint(record.Summary.Seed), true })
+
+	// Parameters of a single kind of run, rows missing in every run are skipped
+	addRow("Parametry", "Tekst wejściowy", general(func(g *SimulationResults) string { return g.InputText }))
+	addRow("Parametry", "Długość danych", general(func(g *SimulationResults) string { return fmt.Sprint(sequenceLength(g.Original)) }))
+	addRow("Parametry", "Typ błędów", general(func(g *SimulationResults) string { return g.ErrorType }))
+	addRow("Parametry", "Model błędów", general(errorModelValue))
+	addRow("Parametry", "Błędy synchronizacji", general(syncValue))
+	addRow("Parametry", "Stopa błędów [%]", general(func(g *SimulationResults) string { return fmt.Sprintf("%.2f", g.ErrorRate) }))
+	addRow("Parametry", "Dekoder", general(func(g *SimulationResults) string { return g.DecoderType }))
+	addRow("Parametry", "Tryb kodowania", general(spreadingValue))
+	addRow("Parametry", "Przesunięcie fazy kodu", general(func(g *SimulationResults) string { return fmt.Sprint(g.CodePhaseOffset) }))
+	addRow("Parametry", "Poziom szumu [%]", cdma(func(c *simulation.CDMAResult) string { return fmt.Sprintf("%.2f", c.NoiseLevel) }))
+	addRow("Parametry", "Liczba użytkowników", cdma(func(c *simulation.CDMAResult) string { return fmt.Sprint(len(c.Users)) }))
+	addRow("Parametry", "Długość kodu", cdma(func(c *simulation.CDMAResult) string { return fmt.Sprint(c.GoldCodeLength) }))
+
+	addRow("Wyniki", "Wprowadzone błędy", general(func(g *SimulationResults) string { return fmt.Sprint(g.ErrorsIntroduced) }))
+	addRow("Wyniki", "Błędne bity", general(func(g *SimulationResults) string { return fmt.Sprint(g.ErrorCount) }))
+	addRow("Wyniki", "BER", general(func(g *SimulationResults) string { return fmt.Sprintf("%.2f%%", g.BER*100) }))
+	addRow("Wyniki", "Wymazane bity", general(func(g *SimulationResults) string { return fmt.Sprint(g.ErasureCount) }))
+	addRow("Wyniki", "Stopa błędów chipów", general(chipErrorRateValue))
+	addRow("Wyniki", "Odległość edycyjna", general(editDistanceValue))
+	addRow("Wyniki", "Oszacowana faza kodu", general(estimatedPhaseValue))
+	addRow("Wyniki", "Autokorelacja (kod)", general(func(g *SimulationResults) string { return fmt.Sprintf("%.4f", g.CodeAutocorr) }))
+	addRow("Wyniki", "Autokorelacja (oryginał)", general(func(g *SimulationResults) string { return fmt.Sprintf("%.4f", g.OriginalAutocorr) }))
+	addRow("Wyniki", "Autokorelacja (zakodowane)", general(func(g *SimulationResults) string { return fmt.Sprintf("%.4f", g.EncodedAutocorr) }))
+	addRow("Wyniki", "Autokorelacja (z błędami)", general(func(g *SimulationResults) string { return fmt.Sprintf("%.4f", g.CorruptedAutocorr) }))
+	addRow("Wyniki", "Złożoność liniowa (kod)", general(func(g *SimulationResults) string { return complexityValue(g.CodeComplexity) }))
+	addRow("Wyniki", "Złożoność liniowa (oryginał)", general(func(g *SimulationResults) string { return complexityValue(g.OriginalComplexity) }))
+	addRow("Wyniki", "Złożoność liniowa (z błędami)", general(func(g *SimulationResults) string { return complexityValue(g.CorruptedComplexity) }))
+	addRow("Wyniki", "Testy NIST (kod)", general(func(g *SimulationResults) string { return randomnessValue(g.CodeRandomness) }))
+	addRow("Wyniki", "Testy NIST (oryginał)", general(func(g *SimulationResults) string { return randomnessValue(g.OriginalRandomness) }))
+	addRow("Wyniki", "Testy NIST (zakodowane)", general(func(g *SimulationResults) string { return randomnessValue(g.EncodedRandomness) }))
+	addRow("Wyniki", "Maks. korelacja wzajemna", cdma(func(c *simulation.CDMAResult) string { return fmt.Sprintf("%.4f", c.MaxCrossCorrelation) }))
+
+	maxUsers := 0
+	for _, record := range records {
+		if record.CDMA != nil {
+			maxUsers = max(maxUsers, len(record.CDMA.Users))
+		}
+	}
+	cdmaUser := func(u int, f func(user *simulation.CDMAUserResult) string) func(*RunRecord) (string, bool) {
+		return func(record *RunRecord) (string, bool) {
+			if record.CDMA == nil || u >= len(record.CDMA.Users) {
+				return "", false
+			}
+			return f(&record.CDMA.Users[u]), true
+		}
+	}
+	for u := range maxUsers {
+		section := "Użytkownik " + simulation.UserLabel(u)
+		addRow(section, "Kod", cdmaUser(u, func(user *simulation.CDMAUserResult) string {
+			if user.UseCodeIndex {
+				return fmt.Sprintf("#%d (%s)", user.CodeIndex, user.CodeName)
+			}
+			return fmt.Sprintf("ziarna %d / %d", user.Seed1, user.Seed2)
+		}))
+		addRow(section, "Współczynnik rozpraszania", cdmaUser(u, func(user *simulation.CDMAUserResult) string { return fmt.Sprint(user.SpreadingFactor) }))
+		addRow(section, "Opóźnienie [chipy]", cdmaUser(u, func(user *simulation.CDMAUserResult) string { return fmt.Sprint(user.Delay) }))
+		addRow(section, "Moc", cdmaUser(u, func(user *simulation.CDMAUserResult) string { return fmt.Sprintf("%.2f", user.Power) }))
+		addRow(section, "Tekst", cdmaUser(u, func(user *simulation.CDMAUserResult) string { return user.InputText }))
+		addRow(section, "Błędne bity", cdmaUser(u, func(user *simulation.CDMAUserResult) string { return fmt.Sprint(user.ErrorCount) }))
+		addRow(section, "BER", cdmaUser(u, func(user *simulation.CDMAUserResult) string { return fmt.Sprintf("%.2f%%", user.BER*100) }))
+		addRow(section, "Interferencja wielodostępna (MAI)", cdmaUser(u, func(user *simulation.CDMAUserResult) string { return fmt.Sprintf("%.4f", user.MAI) }))
+		addRow(section, "Maks. autokorelacja poza szczytem", cdmaUser(u, func(user *simulation.CDMAUserResult) string {
+			return fmt.Sprintf("%.4f", user.MaxOffPeakAutocorrelation)
+		}))
+	}
+	for i := range maxUsers {
+		for j := i + 1; j < maxUsers; j++ {
+			label := fmt.Sprintf("%s - %s", simulation.UserLabel(i), simulation.UserLabel(j))
+			addRow("Korelacja wzajemna", label, cdma(func(c *simulation.CDMAResult) string {
+				if j >= len(c.CrossCorrelation) {
+					return missingValue
+				}
+				return fmt.Sprintf("%.4f", c.CrossCorrelation[i][j])
+			}))
+		}
+	}
+
+	return comparison
+}
+
+// runCodeFamily returns the code family label of a run, runs stored before families were selectable used gold codes
+func runCodeFamily(record *RunRecord) string {
+	switch {
+	case record.General != nil:
+		return codeFamilyLabel(record.General.CodeFamily)
+	case record.CDMA != nil:
+		return codeFamilyLabel(record.CDMA.Family)
+	}
+	return codeFamilyLabel("")
+}
+
+func sequenceLength(seq *simulation.BitSequence) int {
+	if seq == nil {
+		return 0
+	}
+	return seq.Len()
+}
+
+// sequenceOnes returns the number of ones of a sequence, 0 for a missing one
+func sequenceOnes(seq *simulation.BitSequence) int {
+	if seq == nil {
+		return 0
+	}
+	return seq.OnesCount()
+}
+
+// spreadingValue formats the spreading mode of a run, runs saved before DSSS was added only scrambled
+func spreadingValue(g *SimulationResults) string {
+	if g.SpreadingMode == simulation.SpreadingModeDSSS {
+		return fmt.Sprintf("DSSS (SF = %d)", g.SpreadingFactor)
+	}
+	return "skramblowanie"
+}
+
+// chipErrorRateValue formats the errors introduced per transmitted chip, computed from the sequences
+// so runs saved before the rate was stored show it too
+func chipErrorRateValue(g *SimulationResults) string {
+	if g.Encoded == nil || g.Encoded.Len() == 0 {
+		return missingValue
+	}
+	return fmt.Sprintf("%.2f%%", float64(g.ErrorsIntroduced)/float64(g.Encoded.Len())*100)
+}
+
+// errorModelValue describes the error model of a run, missing for runs saved before it was stored
+func errorModelValue(g *SimulationResults) string {
+	if description := errorModelDescription(g.ErrorModel); description != "" {
+		return description
+	}
+	return missingValue
+}
+
+// syncValue describes the synchronization errors of a run, "brak" when none were applied
+func syncValue(g *SimulationResults) string {
+	if description := syncDescription(g.SyncImpairment, symbolUnit(g.SpreadingMode)); description != "" {
+		return description
+	}
+	return "brak"
+}
+
+// editDistanceValue formats the aligned edit distance of the decoded bits, missing for runs
+// without synchronization errors and sequences too far apart to align
+func editDistanceValue(g *SimulationResults) string {
+	if g.Alignment == nil {
+		return missingValue
+	}
+	return fmt.Sprintf("%d (%d/%d/%d)", g.Alignment.Distance, g.Alignment.Substitutions, g.Alignment.Insertions, g.Alignment.Deletions)
+}
+
+// estimatedPhaseValue formats the code phase the decoder synchronized to, missing for decoders
+// that assume phase 0 and runs saved before decoders estimated it
+func estimatedPhaseValue(g *SimulationResults) string {
+	if g.Decoded == nil || g.PreambleLength == 0 {
+		return missingValue
+	}
+	return fmt.Sprint(g.EstimatedCodePhase)
+}
+
+// complexityValue formats the linear complexity of a run, missing when the module was disabled
+func complexityValue(lc *simulation.LinearComplexity) string {
+	if lc == nil {
+		return missingValue
+	}
+	return fmt.Sprintf("%d / %d", lc.Complexity, lc.Length)
+}
+
+// randomnessValue formats the passed and applicable NIST tests of a run, missing when the module was disabled
+func randomnessValue(results []simulation.RandomnessTestResult) string {
+	if results == nil {
+		return missingValue
+	}
+	passed, applicable := simulation.CountPassed(results)
+	return fmt.Sprintf("%d / %d", passed, applicable)
+}
@@ -0,0 +1,310 @@
+package src
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BartiX259/BSO_Projekt/src/simulation"
+)
+
+// Kinds of stored runs
+const (
+	RunKindGeneral = "general"
+	RunKindCDMA    = "cdma"
+)
+
+// Retention defaults, overridable with the BSO_HISTORY_* environment variables
+const (
+	defaultHistoryDir     = "run_history"
+	defaultHistoryMaxRuns = 50 // 0 keeps every run
+	defaultHistoryMaxAge  = 30 // Days, 0 keeps runs forever
+	historyFileSuffix     = ".json"
+)
+
+// RunSummary holds the key parameters and metrics shown in the history list
+type RunSummary struct {
+	N          int
+	Taps1      []uint
+	Taps2      []uint
+	Seed       int64
+	BER        float64 // General: BER of the pipeline, CDMA: mean BER over all users
+	ErrorRate  float64 // General only, percent
+	NoiseLevel float64 // CDMA only, percent
+	NumUsers   int     // CDMA only
+	InputText  string
+}
+
+// RunRecord is a single stored run: its parameters and complete results. Only the session
+// that created the run can list, reopen, compare and delete it. Sessions are not persisted,
+// so the runs of earlier server processes are only removed by the retention limits.
+type RunRecord struct {
+	ID        string
+	Kind      string
+	SessionID string
+	CreatedAt time.Time
+	Summary   RunSummary
+	General   *SimulationResults     `json:",omitempty"`
+	CDMA      *simulation.CDMAResult `json:",omitempty"`
+}
+
+// RunHistory stores runs as one JSON file per run and enforces the retention limits
+type RunHistory struct {
+	mutex   sync.Mutex
+	dir     string
+	maxRuns int
+	maxAge  time.Duration
+}
+
+var runIDPattern = regexp.MustCompile(`^[a-z]+_[0-9]{8}_[0-9]{6}_[0-9a-f]+$`)
+
+var runHistory = NewRunHistory(
+	envWithDefault("BSO_HISTORY_DIR", defaultHistoryDir),
+	envIntWithDefault("BSO_HISTORY_MAX_RUNS", defaultHistoryMaxRuns),
+	time.Duration(envIntWithDefault("BSO_HISTORY_MAX_AGE_DAYS", defaultHistoryMaxAge))*24*time.Hour,
+)
+
+// NewRunHistory creates a history in dir keeping at most maxRuns runs no older than maxAge.
+// A limit <= 0 disables it.
+func NewRunHistory(dir string, maxRuns int, maxAge time.Duration) *RunHistory {
+	ensureDirExists(dir)
+	return &RunHistory{dir: dir, maxRuns: maxRuns, maxAge: maxAge}
+}
+
+func newGeneralRunRecord(sessionID string, results *SimulationResults) *RunRecord {
+	return &RunRecord{
+		Kind:      RunKindGeneral,
+		SessionID: sessionID,
+		Summary: RunSummary{
+			N:         results.GoldN,
+			Taps1:     results.GoldTaps1,
+			Taps2:     results.GoldTaps2,
+			Seed:      results.Seed,
+			BER:       float64(results.BER),
+			ErrorRate: results.ErrorRate,
+			InputText: results.InputText,
+		},
+		General: results,
+	}
+}
+
+func newCDMARunRecord(sessionID string, result *simulation.CDMAResult) *RunRecord {
+	summary := RunSummary{
+		N:          int(result.N),
+		Taps1:      result.Poly1,
+		Taps2:      result.Poly2,
+		Seed:       result.Seed,
+		NoiseLevel: result.NoiseLevel,
+		NumUsers:   len(result.Users),
+	}
+	texts := make([]string, len(result.Users))
+	for i, user := range result.Users {
+		summary.BER += float64(user.BER) / float64(len(result.Users))
+		texts[i] = user.InputText
+	}
+	summary.InputText = strings.Join(texts, " | ")
+	return &RunRecord{Kind: RunKindCDMA, SessionID: sessionID, Summary: summary, CDMA: result}
+}
+
+// Save assigns the record an ID, writes it to disk and drops runs exceeding the retention limits
+func (h *RunHistory) Save(record *RunRecord) error {
+	record.CreatedAt = time.Now()
+	suffix := make([]byte, 3)
+	if _, err := rand.Read(suffix); err != nil {
+		return err
+	}
+	record.ID = fmt.Sprintf("%s_%s_%s", record.Kind, record.CreatedAt.Format("20060102_150405"), hex.EncodeToString(suffix))
+
+	content, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encoding run %s: %w", record.ID, err)
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	// Write to a temporary file first so a crash never leaves a truncated run behind
+	tmp, err := os.CreateTemp(h.dir, "tmp_*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), h.path(record.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+
+	h.applyRetention()
+	return nil
+}
+
+// List returns every stored run of the session without its results, newest first
+func (h *RunHistory) List(sessionID string) ([]RunRecord, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	ids, err := h.ids()
+	if err != nil {
+		return nil, err
+	}
+	records := make([]RunRecord, 0, len(ids))
+	for _, id := range ids {
+		record, err := h.read(id)
+		if err != nil {
+			log.Printf("Skipping unreadable run %s: %v", id, err)
+			continue
+		}
+		if record.SessionID != sessionID {
+			continue
+		}
+		record.General = nil
+		record.CDMA = nil
+		records = append(records, *record)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+// Load returns the complete stored run of the session with the given ID
+func (h *RunHistory) Load(id, sessionID string) (*RunRecord, error) {
+	if !runIDPattern.MatchString(id) {
+		return nil, fmt.Errorf("invalid run ID %q", id)
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	return h.readOwned(id, sessionID)
+}
+
+// Delete removes the stored run of the session with the given ID
+func (h *RunHistory) Delete(id, sessionID string) error {
+	if !runIDPattern.MatchString(id) {
+		return fmt.Errorf("invalid run ID %q", id)
+	}
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if _, err := h.readOwned(id, sessionID); err != nil {
+		return err
+	}
+	return os.Remove(h.path(id))
+}
+
+func (h *RunHistory) path(id string) string {
+	return filepath.Join(h.dir, id+historyFileSuffix)
+}
+
+// ids returns the IDs of all stored runs. Must be called with the history locked.
+func (h *RunHistory) ids() ([]string, error) {
+	entries, err := os.ReadDir(h.dir)
+	if err != nil {
+		return nil, err
+	}
+	var ids []string
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), historyFileSuffix)
+		if ok && !entry.IsDir() && runIDPattern.MatchString(id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// read decodes a stored run. Must be called with the history locked.
+func (h *RunHistory) read(id string) (*RunRecord, error) {
+	content, err := os.ReadFile(h.path(id))
+	if err != nil {
+		return nil, err
+	}
+	var record RunRecord
+	if err := json.Unmarshal(content, &record); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// readOwned decodes a stored run of the session, runs of other sessions are reported as missing.
+// Must be called with the history locked.
+func (h *RunHistory) readOwned(id, sessionID string) (*RunRecord, error) {
+	record, err := h.read(id)
+	if err != nil {
+		return nil, err
+	}
+	if record.SessionID != sessionID {
+		return nil, fmt.Errorf("run %s belongs to another session: %w", id, os.ErrNotExist)
+	}
+	return record, nil
+}
+
+// applyRetention removes runs older than maxAge and all but the newest maxRuns.
+// Must be called with the history locked.
+func (h *RunHistory) applyRetention() {
+	ids, err := h.ids()
+	if err != nil {
+		log.Printf("Error listing run history: %v", err)
+		return
+	}
+	type storedRun struct {
+		id      string
+		modTime time.Time
+	}
+	runs := make([]storedRun, 0, len(ids))
+	for _, id := range ids {
+		info, err := os.Stat(h.path(id))
+		if err != nil {
+			continue
+		}
+		runs = append(runs, storedRun{id, info.ModTime()})
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].modTime.After(runs[j].modTime)
+	})
+
+	for i, run := range runs {
+		tooMany := h.maxRuns > 0 && i >= h.maxRuns
+		tooOld := h.maxAge > 0 && time.Since(run.modTime) > h.maxAge
+		if tooMany || tooOld {
+			if err := os.Remove(h.path(run.id)); err != nil {
+				log.Printf("Error removing old run %s: %v", run.id, err)
+			}
+		}
+	}
+}
+
+func envWithDefault(name, defaultVal string) string {
+	if val := strings.TrimSpace(os.Getenv(name)); val != "" {
+		return val
+	}
+	return defaultVal
+}
+
+func envIntWithDefault(name string, defaultVal int) int {
+	val := strings.TrimSpace(os.Getenv(name))
+	if val == "" {
+		return defaultVal
+	}
+	parsed, err := strconv.Atoi(val)
+	if err != nil {
+		log.Printf("Invalid value %q of %s, using %d", val, name, defaultVal)
+		return defaultVal
+	}
+	return parsed
+}
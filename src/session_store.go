@@ -0,0 +1,129 @@
+package src
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	sessionCookieName      = "bso_session"
+	sessionTTL             = 2 * time.Hour    // Sessions idle for longer are dropped
+	sessionCleanupInterval = 10 * time.Minute // Minimum time between two scans for expired sessions
+)
+
+// Session holds the simulation state of a single browser, so concurrent users
+// of the server do not overwrite each other's results and downloads
+type Session struct {
+	ID      string
+	General *SimulationResults
+	CDMA    *CDMASimulationState
+	Sweep   *CDMASweepState
+
+	fileMutex       sync.RWMutex
+	generalFilePath string
+	cdmaFilePath    string
+
+	lastSeen time.Time // Guarded by the store mutex
+}
+
+// SessionStore keeps the sessions keyed by the session cookie and drops idle ones
+type SessionStore struct {
+	mutex       sync.Mutex
+	sessions    map[string]*Session
+	ttl         time.Duration
+	lastCleanup time.Time
+}
+
+// NewSessionStore creates an empty store whose sessions expire after ttl of inactivity
+func NewSessionStore(ttl time.Duration) *SessionStore {
+	return &SessionStore{sessions: make(map[string]*Session), ttl: ttl, lastCleanup: time.Now()}
+}
+
+var sessionStore = NewSessionStore(sessionTTL)
+
+func newSession(id string) *Session {
+	return &Session{
+		ID:      id,
+		General: &SimulationResults{},
+		CDMA:    &CDMASimulationState{},
+		Sweep:   &CDMASweepState{},
+	}
+}
+
+// sessionFor returns the session of the request, starting a new one if needed.
+// Must be called before the response header is written, as it may set the session cookie.
+func sessionFor(w http.ResponseWriter, r *http.Request) *Session {
+	return sessionStore.Get(w, r)
+}
+
+// Get returns the session identified by the request cookie. Unknown or expired
+// sessions are replaced by a fresh one and the cookie is (re)issued.
+func (s *SessionStore) Get(w http.ResponseWriter, r *http.Request) *Session {
+	now := time.Now()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if now.Sub(s.lastCleanup) >= sessionCleanupInterval {
+		s.removeExpired(now)
+	}
+
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		if session, ok := s.sessions[cookie.Value]; ok && now.Sub(session.lastSeen) < s.ttl {
+			session.lastSeen = now
+			return session
+		}
+	}
+
+	session := newSession(newSessionID())
+	session.lastSeen = now
+	s.sessions[session.ID] = session
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    session.ID,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return session
+}
+
+// removeExpired drops every session idle for longer than the ttl. Must be called with the store locked.
+func (s *SessionStore) removeExpired(now time.Time) {
+	for id, session := range s.sessions {
+		if now.Sub(session.lastSeen) >= s.ttl {
+			delete(s.sessions, id)
+		}
+	}
+	s.lastCleanup = now
+}
+
+func newSessionID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("Failed to generate session ID: %v", err)
+	}
+	return hex.EncodeToString(buf)
+}
+
+func (s *Session) setGeneralFilePath(path string) {
+	s.fileMutex.Lock()
+	s.generalFilePath = path
+	s.fileMutex.Unlock()
+}
+
+func (s *Session) setCDMAFilePath(path string) {
+	s.fileMutex.Lock()
+	s.cdmaFilePath = path
+	s.fileMutex.Unlock()
+}
+
+func (s *Session) filePaths() (general, cdma string) {
+	s.fileMutex.RLock()
+	defer s.fileMutex.RUnlock()
+	return s.generalFilePath, s.cdmaFilePath
+}
@@ -1,17 +1,120 @@
 package simulation
 
-// Calculates the bit error rate based on the original and decoded sequence
+import "math"
+
+// Calculates the bit error rate based on the original and decoded sequence. Sequences of different
+// length are compared bit by bit from the start, see CountBitErrors.
 func CalculateBER(originalSequence BitSequence, decodedSequence BitSequence) float32 {
-	if originalSequence.length != decodedSequence.length {
-		panic("Original sequence length not equal to decoded length")
+	errorCount := CountBitErrors(&originalSequence, &decodedSequence)
+	return float32(errorCount) / float32(max(originalSequence.length, decodedSequence.length))
+}
+
+// Counts the positions at which two sequences differ when compared from the first bit,
+// every bit of the longer sequence past the end of the shorter one counts as an error
+func CountBitErrors(a, b *BitSequence) int {
+	if a.length == b.length {
+		return a.HammingDistance(b)
+	}
+	common := min(a.length, b.length)
+	return a.Slice(0, common).HammingDistance(b.Slice(0, common)) + max(a.length, b.length) - common
+}
+
+// Counts the errors and erasures of a decoded sequence with erased bits, compared from the first bit
+// like CountBitErrors. Erased bits are not errors, the bits of the longer sequence past the end of the
+// shorter one are errors unless erased. Erased may be nil when no bit was erased.
+func CountErasuresAndErrors(original, decoded, erased *BitSequence) (errors int, erasures int) {
+	if erased == nil {
+		return CountBitErrors(original, decoded), 0
+	}
+	common := min(original.length, decoded.length)
+	decided := erased.Slice(0, common).Not()
+	errors = original.Slice(0, common).Xor(decoded.Slice(0, common)).And(decided).OnesCount()
+	if decoded.length > common {
+		errors += decoded.length - common - erased.Slice(common, decoded.length).OnesCount()
 	}
-	errorCount := 0
-	for i := range originalSequence.length {
-		b1 := originalSequence.Get(i)
-		b2 := decodedSequence.Get(i)
-		if b1 != b2 {
-			errorCount += 1
+	errors += original.length - common
+	return errors, erased.OnesCount()
+}
+
+// Most cells of the dynamic program AlignSequences visits before giving up
+const maxAlignmentCells = 1 << 27
+
+// Minimal edit alignment of a received sequence against the original
+type Alignment struct {
+	Distance      int // Levenshtein distance, the sum of the operations below
+	Substitutions int // Flipped bits
+	Insertions    int // Bits of the received sequence missing in the original
+	Deletions     int // Bits of the original missing in the received sequence
+}
+
+// Aligns the received sequence to the original with the fewest substitutions, insertions and deletions.
+// Unlike CountBitErrors a single lost or extra bit costs one operation instead of shifting all bits
+// after it. The dynamic program only visits a band around the diagonal, which is widened until it
+// holds the optimal alignment, so the cost is O(N d) for a distance d. Returns false when the band
+// would need more than maxAlignmentCells cells.
+func AlignSequences(original, received *BitSequence) (Alignment, bool) {
+	n, m := original.length, received.length
+	band := max(abs(n-m), 16)
+	for (2*band+1)*(n+1) <= maxAlignmentCells {
+		alignment := alignBanded(original, received, band)
+		if alignment.Distance <= band || band >= max(n, m) {
+			return alignment, true
 		}
+		band *= 2
+	}
+	return Alignment{}, false
+}
+
+// Edit distance restricted to cells with |i - j| <= band, exact whenever the distance is at most band.
+// Every cell keeps the operation counts of its best path, ties prefer substitutions, then deletions.
+func alignBanded(original, received *BitSequence, band int) Alignment {
+	n, m := original.length, received.length
+	width := 2*band + 1
+	unreachable := Alignment{Distance: math.MaxInt / 2}
+	prev := make([]Alignment, width)
+	cur := make([]Alignment, width)
+	// Row i holds columns j = i-band..i+band at index j - i + band
+	for t := range width {
+		prev[t] = unreachable
+		if j := t - band; j >= 0 && j <= m {
+			prev[t] = Alignment{Distance: j, Insertions: j}
+		}
+	}
+	for i := 1; i <= n; i++ {
+		for t := range width {
+			j := i - band + t
+			cur[t] = unreachable
+			if j < 0 || j > m {
+				continue
+			}
+			if j == 0 {
+				cur[t] = Alignment{Distance: i, Deletions: i}
+				continue
+			}
+			// Diagonal: the bits are matched, a substitution if they differ
+			best := prev[t]
+			if original.Get(i-1) != received.Get(j-1) {
+				best.Distance++
+				best.Substitutions++
+			}
+			// Up: original bit i-1 was deleted
+			if t+1 < width && prev[t+1].Distance+1 < best.Distance {
+				best = prev[t+1]
+				best.Distance++
+				best.Deletions++
+			}
+			// Left: received bit j-1 was inserted
+			if t > 0 && cur[t-1].Distance+1 < best.Distance {
+				best = cur[t-1]
+				best.Distance++
+				best.Insertions++
+			}
+			cur[t] = best
+		}
+		prev, cur = cur, prev
+	}
+	if abs(n-m) > band {
+		return unreachable
 	}
-	return float32(errorCount) / float32(originalSequence.length)
+	return prev[m-n+band]
 }
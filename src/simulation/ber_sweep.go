@@ -0,0 +1,189 @@
+package simulation
+
+import (
+	"context"
+	"math"
+	"time"
+)
+
+// Noise axis of a BER sweep
+const (
+	SweepModeEbN0  = "ebn0"  // Points are Eb/N0 values in dB of a unit-power user
+	SweepModeSigma = "sigma" // Points are noise standard deviations per chip
+)
+
+// Parameters of a Monte Carlo BER sweep over the CDMA link
+type SweepConfig struct {
+	N      uint
+	Poly1  []uint
+	Poly2  []uint
+	Family string           // Code family, see CodeFamilyGold
+	Users  []CDMAUserConfig // Text is ignored, every trial sends random bits
+
+	Mode  string // SweepModeEbN0 or SweepModeSigma
+	Start float64
+	Stop  float64
+	Step  float64
+
+	BitsPerTrial int // Data bits sent per user in a single trial, more for users with shorter OVSF codes
+	TargetErrors int // A point is finished once every user collected this many errors...
+	MaxBits      int // ...or this many bits were sent per user
+
+	Workers int   // Goroutines running trials, < 1 uses one per CPU
+	Seed    int64 // Master seed of the trial RNG streams
+}
+
+// Trials run between two checks of the stopping criterion. It is independent of the
+// number of workers so that a sweep always stops after the same trials.
+const sweepBatchTrials = 16
+
+// BER of a single user at one sweep point
+type SweepUserPoint struct {
+	Errors int
+	Bits   int
+	BER    float64
+}
+
+// Accumulated results of all trials at one noise value
+type SweepPoint struct {
+	Value      float64 // Swept value (Eb/N0 in dB or noise sigma, depending on the mode)
+	EbN0dB     float64
+	NoiseSigma float64
+	Trials     int
+	Users      []SweepUserPoint
+}
+
+// BER curve of every user over the swept noise range
+type SweepResult struct {
+	Config     SweepConfig
+	UserLabels []string
+	CodeLength int
+	Points     []SweepPoint
+	Timestamp  string
+}
+
+// Returns the BER curve of the given user as (x, BER) pairs
+func (r *SweepResult) Curve(user int) ([]float64, []float64) {
+	xs := make([]float64, len(r.Points))
+	bers := make([]float64, len(r.Points))
+	for i, point := range r.Points {
+		xs[i] = point.Value
+		bers[i] = point.Users[user].BER
+	}
+	return xs, bers
+}
+
+// Returns the values swept by the configuration, from Start to Stop inclusive
+func (c SweepConfig) Values() []float64 {
+	if c.Step <= 0 || c.Stop < c.Start {
+		return []float64{c.Start}
+	}
+	count := int(math.Floor((c.Stop-c.Start)/c.Step+1e-9)) + 1
+	values := make([]float64, count)
+	for i := range count {
+		values[i] = c.Start + float64(i)*c.Step
+	}
+	return values
+}
+
+// Returns an upper bound of the chips all users send over the sweep, reached when no point
+// stops early at TargetErrors. Every trial sends BitsPerTrial frames of the longest code.
+func (c SweepConfig) MaxChips() int {
+	bitsPerTrial := max(c.BitsPerTrial, 1)
+	trials := (max(c.MaxBits, bitsPerTrial) + bitsPerTrial - 1) / bitsPerTrial
+	return len(c.Values()) * trials * bitsPerTrial * CodeFamilyLength(c.Family, c.N) * len(c.Users)
+}
+
+// Converts Eb/N0 in dB of a unit-power user to the noise standard deviation per chip.
+// The sweep uses the length of the longest code of the link.
+// With unit chip amplitude Eb = codeLength and N0 = 2*sigma^2.
+func EbN0ToSigma(ebn0dB float64, codeLength int) float64 {
+	ebn0 := math.Pow(10, ebn0dB/10)
+	return math.Sqrt(float64(codeLength) / (2 * ebn0))
+}
+
+// Converts the noise standard deviation per chip to Eb/N0 in dB of a unit-power user
+func SigmaToEbN0(sigma float64, codeLength int) float64 {
+	if sigma <= 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(float64(codeLength)/(2*sigma*sigma))
+}
+
+// Runs the Monte Carlo BER sweep described by the configuration. The sweep stops between
+// trials once ctx is done and returns the error of ctx.
+func SweepCDMABER(ctx context.Context, config SweepConfig) (*SweepResult, error) {
+	if config.BitsPerTrial < 1 {
+		config.BitsPerTrial = 1
+	}
+	if config.MaxBits < config.BitsPerTrial {
+		config.MaxBits = config.BitsPerTrial
+	}
+
+	link := NewCDMALink(config.N, config.Poly1, config.Poly2, config.Family, config.Users)
+
+	result := &SweepResult{
+		Config:     config,
+		UserLabels: make([]string, len(link.Users)),
+		CodeLength: link.CodeLength,
+	}
+	for u := range link.Users {
+		result.UserLabels[u] = UserLabel(u)
+	}
+
+	for pointIndex, value := range config.Values() {
+		point := SweepPoint{Value: value}
+		if config.Mode == SweepModeSigma {
+			point.NoiseSigma = value
+			point.EbN0dB = SigmaToEbN0(value, link.CodeLength)
+		} else {
+			point.EbN0dB = value
+			point.NoiseSigma = EbN0ToSigma(value, link.CodeLength)
+		}
+
+		point.Users = make([]SweepUserPoint, len(link.Users))
+		runner := NewTrialRunner(config.Workers, DeriveSeed(config.Seed, pointIndex))
+		trial := CDMATrial(link, config.BitsPerTrial, point.NoiseSigma)
+		userBits := make([]int, len(link.Users))
+		for u := range link.Users {
+			userBits[u] = link.BitsPerTrial(u, config.BitsPerTrial)
+		}
+		for !point.finished(config) {
+			remainingTrials := 0
+			for u, bits := range userBits {
+				remainingTrials = max(remainingTrials, (config.MaxBits-point.Users[u].Bits+bits-1)/bits)
+			}
+			batch := min(sweepBatchTrials, remainingTrials)
+			errors, err := runner.Run(ctx, point.Trials, batch, trial)
+			if err != nil {
+				return nil, err
+			}
+			point.accumulate(errors, batch, userBits)
+		}
+		result.Points = append(result.Points, point)
+	}
+
+	result.Timestamp = time.Now().Format(time.RFC1123)
+	return result, nil
+}
+
+// Adds the merged error counts of a batch of trials to the point, userBits are the bits
+// every user sends in one trial
+func (p *SweepPoint) accumulate(errors []int, trials int, userBits []int) {
+	p.Trials += trials
+	for u := range p.Users {
+		p.Users[u].Errors += errors[u]
+		p.Users[u].Bits += trials * userBits[u]
+		p.Users[u].BER = float64(p.Users[u].Errors) / float64(p.Users[u].Bits)
+	}
+}
+
+// Reports whether every user reached the target error count or the bit limit
+func (p *SweepPoint) finished(config SweepConfig) bool {
+	for _, user := range p.Users {
+		if user.Bits < config.MaxBits && (config.TargetErrors <= 0 || user.Errors < config.TargetErrors) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package simulation
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Linear complexity of a sequence: the length L of the shortest LFSR generating it, together
+// with its connection polynomial C(x) = 1 + c_1 x + ... + c_L x^L, where s_j = c_1 s_(j-1) XOR
+// ... XOR c_L s_(j-L). Profile[k] is the linear complexity of the first k+1 bits.
+// An m-sequence of an n-bit LFSR has L = n, a random sequence of length N has L close to N/2.
+type LinearComplexity struct {
+	Length     int
+	Complexity int
+	Connection *BitSequence // c_0..c_L
+	Profile    []int
+}
+
+// Compute the linear complexity of a sequence with the Berlekamp-Massey algorithm.
+// The polynomials and the reversed sequence are kept in words, so every step costs O(L/64).
+func BerlekampMassey(seq *BitSequence) *LinearComplexity {
+	length := seq.length
+	words := (length+1+63)/64 + 1
+
+	// rev holds the sequence backwards, bit j = s_(N-1-j), so the bits s_k, s_(k-1), ..., s_(k-L)
+	// form a forward window of rev starting at N-1-k and line up with c_0..c_L
+	rev := make([]uint64, words)
+	for i := range length {
+		j := length - 1 - i
+		rev[j/64] |= uint64(seq.Get(i)) << (j % 64)
+	}
+
+	c := make([]uint64, words) // Current connection polynomial
+	b := make([]uint64, words) // Connection polynomial before the last length change
+	t := make([]uint64, words)
+	c[0], b[0] = 1, 1
+	complexity, m := 0, 1
+	profile := make([]int, length)
+
+	for k := range length {
+		if discrepancy(c, rev, length-1-k, complexity) == 1 {
+			copy(t, c)
+			xorShifted(c, b, m, complexity+m)
+			if 2*complexity <= k {
+				complexity = k + 1 - complexity
+				b, t = t, b
+				m = 1
+			} else {
+				m++
+			}
+		} else {
+			m++
+		}
+		profile[k] = complexity
+	}
+
+	connection := NewBitSequence(complexity + 1)
+	copy(connection.bits, c[:len(connection.bits)])
+	// The last word of c may hold bits of a longer polynomial that were cancelled, clear them
+	if rem := (complexity + 1) % 64; rem != 0 {
+		connection.bits[len(connection.bits)-1] &= 1<<rem - 1
+	}
+	return &LinearComplexity{
+		Length:     length,
+		Complexity: complexity,
+		Connection: connection,
+		Profile:    profile,
+	}
+}
+
+// Parity of c_0..c_degree AND the window of rev starting at offset
+func discrepancy(c, rev []uint64, offset, degree int) uint8 {
+	word, shift := offset/64, offset%64
+	ones := 0
+	for i := 0; i <= degree/64; i++ {
+		window := rev[word+i] >> shift
+		if shift != 0 && word+i+1 < len(rev) {
+			window |= rev[word+i+1] << (64 - shift)
+		}
+		ones += bits.OnesCount64(c[i] & window)
+	}
+	return uint8(ones % 2)
+}
+
+// XOR src multiplied by x^m into dst, src has degree below limit-m
+func xorShifted(dst, src []uint64, m, limit int) {
+	word, shift := m/64, m%64
+	for i := 0; i <= (limit-m)/64 && word+i < len(dst); i++ {
+		dst[word+i] ^= src[i] << shift
+		if shift != 0 && word+i+1 < len(dst) {
+			dst[word+i+1] ^= src[i] >> (64 - shift)
+		}
+	}
+}
+
+// Return the ratio of the linear complexity to the sequence length, about 0.5 for random data
+func (lc *LinearComplexity) Ratio() float64 {
+	return float64(lc.Complexity) / float64(lc.Length)
+}
+
+// Return the connection polynomial in descending powers, e.g. "x^4 + x + 1"
+func (lc *LinearComplexity) ConnectionString() string {
+	var terms []string
+	for i := lc.Complexity; i >= 0; i-- {
+		if lc.Connection.Get(i) == 0 {
+			continue
+		}
+		switch i {
+		case 0:
+			terms = append(terms, "1")
+		case 1:
+			terms = append(terms, "x")
+		default:
+			terms = append(terms, fmt.Sprintf("x^%d", i))
+		}
+	}
+	return strings.Join(terms, " + ")
+}
+
+// Return the taps of a Fibonacci LFSR of Complexity bits generating the sequence, c_i = 1 is tap i-1.
+// Started from the state with bit i = s_(L-1-i), the register outputs s_L, s_(L+1), ...
+// C(x) is the reciprocal of the feedback polynomial of TapsToPolynomial.
+func (lc *LinearComplexity) Taps() []uint {
+	var taps []uint
+	for i := lc.Complexity; i >= 1; i-- {
+		if lc.Connection.Get(i) == 1 {
+			taps = append(taps, uint(i-1))
+		}
+	}
+	return taps
+}
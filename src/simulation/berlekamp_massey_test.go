@@ -0,0 +1,104 @@
+package simulation
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// Return a sequence of the given bits
+func sequenceOf(bits ...uint8) *BitSequence {
+	seq := NewBitSequence(len(bits))
+	for i, bit := range bits {
+		seq.Set(i, bit)
+	}
+	return seq
+}
+
+// Linear complexity of the first length bits by trying every connection polynomial, shortest first
+func bruteForceComplexity(seq *BitSequence, length int) int {
+	for l := range length {
+		for c := range 1 << l {
+			generates := true
+			for j := l; j < length && generates; j++ {
+				var bit uint8
+				for i := 1; i <= l; i++ {
+					bit ^= uint8(c>>(i-1)&1) & seq.Get(j-i)
+				}
+				generates = bit == seq.Get(j)
+			}
+			if generates {
+				return l
+			}
+		}
+	}
+	return length
+}
+
+func TestBerlekampMasseyMSequences(t *testing.T) {
+	for n := uint(5); n <= 10; n++ {
+		t.Run(fmt.Sprintf("n=%d", n), func(t *testing.T) {
+			taps := PrimitiveTaps(n)
+			lfsr := NewLFSR(1, taps, n)
+			seq := NewBitSequence(2 * (pow2(n) - 1))
+			for i := range seq.Len() {
+				seq.Set(i, lfsr.Shift())
+			}
+			lc := BerlekampMassey(seq)
+			if lc.Complexity != int(n) {
+				t.Fatalf("linear complexity %d, want %d", lc.Complexity, n)
+			}
+			// The connection polynomial is the feedback polynomial of the register
+			if got, want := TapsToPolynomial(n, lc.Taps()), TapsToPolynomial(n, taps); got != want {
+				t.Fatalf("connection polynomial gives %v, want %v", got, want)
+			}
+			if lc.Profile[2*n-1] != int(n) || lc.Profile[len(lc.Profile)-1] != int(n) {
+				t.Fatalf("profile does not settle at %d after 2n bits: %v", n, lc.Profile[:2*n])
+			}
+		})
+	}
+}
+
+func TestBerlekampMasseyKnownAnswers(t *testing.T) {
+	tests := []struct {
+		name       string
+		seq        *BitSequence
+		complexity int
+		connection string
+		profile    []int
+	}{
+		{"all zeros", NewBitSequence(8), 0, "1", []int{0, 0, 0, 0, 0, 0, 0, 0}},
+		{"trailing one", sequenceOf(0, 0, 0, 0, 0, 1), 6, "x^6 + 1", []int{0, 0, 0, 0, 0, 6}},
+		{"leading one", sequenceOf(1, 0, 0, 0, 0), 1, "1", []int{1, 1, 1, 1, 1}},
+		{"alternating", sequenceOf(1, 0, 1, 0, 1, 0), 2, "x^2 + 1", []int{1, 1, 2, 2, 2, 2}},
+		{"m-sequence x^3 + x + 1", sequenceOf(1, 0, 0, 1, 0, 1, 1), 3, "x^3 + x^2 + 1", []int{1, 1, 1, 3, 3, 3, 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lc := BerlekampMassey(test.seq)
+			if lc.Complexity != test.complexity {
+				t.Fatalf("linear complexity %d, want %d", lc.Complexity, test.complexity)
+			}
+			if got := lc.ConnectionString(); got != test.connection {
+				t.Fatalf("connection polynomial %s, want %s", got, test.connection)
+			}
+			if !slices.Equal(lc.Profile, test.profile) {
+				t.Fatalf("profile %v, want %v", lc.Profile, test.profile)
+			}
+		})
+	}
+}
+
+func TestBerlekampMasseyProfileMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for range 50 {
+		seq := RandomSequence(12, rng)
+		lc := BerlekampMassey(seq)
+		for k, complexity := range lc.Profile {
+			if want := bruteForceComplexity(seq, k+1); complexity != want {
+				t.Fatalf("%s: profile[%d] = %d, brute force gives %d", seq, k, complexity, want)
+			}
+		}
+	}
+}
@@ -1,5 +1,7 @@
 package simulation
 
+import "encoding/json"
+
 // Stores a sequence of bits up to any length N
 type BitSequence struct {
 	bits  []uint64
@@ -49,13 +51,28 @@ func (b *BitSequence) Len() int {
 
 // Convert bit sequence to string
 func (b *BitSequence) String() string {
-	str := ""
+	str := make([]byte, b.length)
 	for i := range b.length {
-		if b.Get(i) == 1 {
-			str += "1"
-		} else {
-			str += "0"
-		}
+		str[i] = '0' + byte(b.bits[i/64]>>(i%64)&1)
+	}
+	return string(str)
+}
+
+// Encode the sequence as a JSON string of '0' and '1' characters
+func (b *BitSequence) MarshalJSON() ([]byte, error) {
+	return json.Marshal(b.String())
+}
+
+// Decode a JSON string of '0' and '1' characters produced by MarshalJSON
+func (b *BitSequence) UnmarshalJSON(data []byte) error {
+	var str string
+	if err := json.Unmarshal(data, &str); err != nil {
+		return err
+	}
+	seq, err := BitSequenceFromString(str)
+	if err != nil {
+		return err
 	}
-	return str
+	*b = *seq
+	return nil
 }
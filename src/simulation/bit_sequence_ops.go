@@ -0,0 +1,258 @@
+package simulation
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"math/bits"
+)
+
+// Word-level operations on whole sequences. Bits past the length in the last word are kept at zero,
+// so words can be compared and counted without masking.
+
+// Return a copy of the sequence
+func (b *BitSequence) Clone() *BitSequence {
+	clone := NewBitSequence(b.length)
+	copy(clone.bits, b.bits)
+	return clone
+}
+
+// Report whether both sequences have the same length and bits
+func (b *BitSequence) Equal(other *BitSequence) bool {
+	if b.length != other.length {
+		return false
+	}
+	for i, word := range b.bits {
+		if word != other.bits[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the bitwise XOR of two equally long sequences
+func (b *BitSequence) Xor(other *BitSequence) *BitSequence {
+	b.checkSameLength(other)
+	result := NewBitSequence(b.length)
+	for i, word := range b.bits {
+		result.bits[i] = word ^ other.bits[i]
+	}
+	return result
+}
+
+// Return the bitwise AND of two equally long sequences
+func (b *BitSequence) And(other *BitSequence) *BitSequence {
+	b.checkSameLength(other)
+	result := NewBitSequence(b.length)
+	for i, word := range b.bits {
+		result.bits[i] = word & other.bits[i]
+	}
+	return result
+}
+
+// Return the sequence with every bit inverted
+func (b *BitSequence) Not() *BitSequence {
+	result := NewBitSequence(b.length)
+	for i, word := range b.bits {
+		result.bits[i] = ^word
+	}
+	result.clearTail()
+	return result
+}
+
+// Return the number of ones
+func (b *BitSequence) OnesCount() int {
+	count := 0
+	for _, word := range b.bits {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// Return the number of positions at which two equally long sequences differ
+func (b *BitSequence) HammingDistance(other *BitSequence) int {
+	b.checkSameLength(other)
+	distance := 0
+	for i, word := range b.bits {
+		distance += bits.OnesCount64(word ^ other.bits[i])
+	}
+	return distance
+}
+
+// Return the bits start..end-1 as a new sequence, the range must not be empty
+func (b *BitSequence) Slice(start, end int) *BitSequence {
+	if start < 0 || end > b.length || start >= end {
+		panic("Slice range out of bounds")
+	}
+	result := NewBitSequence(end - start)
+	word, shift := start/64, start%64
+	for i := range result.bits {
+		result.bits[i] = b.bits[word+i] >> shift
+		if shift != 0 && word+i+1 < len(b.bits) {
+			result.bits[i] |= b.bits[word+i+1] << (64 - shift)
+		}
+	}
+	result.clearTail()
+	return result
+}
+
+// Return the sequence followed by other
+func (b *BitSequence) Append(other *BitSequence) *BitSequence {
+	return Concat(b, other)
+}
+
+// Return the sequences joined one after another
+func Concat(seqs ...*BitSequence) *BitSequence {
+	length := 0
+	for _, seq := range seqs {
+		length += seq.length
+	}
+	result := NewBitSequence(length)
+	offset := 0
+	for _, seq := range seqs {
+		result.orShifted(seq, offset)
+		offset += seq.length
+	}
+	return result
+}
+
+// Return the sequence cyclically rotated left by k bits, bit i of the result is bit (i+k) mod L.
+// Negative k rotates right.
+func (b *BitSequence) Rotate(k int) *BitSequence {
+	k = (k%b.length + b.length) % b.length
+	if k == 0 {
+		return b.Clone()
+	}
+	return Concat(b.Slice(k, b.length), b.Slice(0, k))
+}
+
+// Return the sequence repeated cyclically and cut to the given length
+func (b *BitSequence) Repeat(length int) *BitSequence {
+	result := NewBitSequence(length)
+	for offset := 0; offset < length; offset += b.length {
+		result.orShifted(b, offset)
+	}
+	result.clearTail()
+	return result
+}
+
+// Pack the bits into bytes, the first bit is the most significant bit of the first byte
+// and the last byte is padded with zeros
+func (b *BitSequence) Bytes() []byte {
+	data := make([]byte, (b.length+7)/8)
+	for i := range data {
+		data[i] = bits.Reverse8(byte(b.bits[i/8] >> (8 * (i % 8))))
+	}
+	return data
+}
+
+// Create a sequence of 8 bits per byte in the order of Bytes
+func BitSequenceFromBytes(data []byte) *BitSequence {
+	seq := NewBitSequence(8 * len(data))
+	for i, c := range data {
+		seq.bits[i/8] |= uint64(bits.Reverse8(c)) << (8 * (i % 8))
+	}
+	return seq
+}
+
+// Return the bytes of the sequence as lowercase hexadecimal
+func (b *BitSequence) Hex() string {
+	return hex.EncodeToString(b.Bytes())
+}
+
+// Return the bytes of the sequence in standard base64
+func (b *BitSequence) Base64() string {
+	return base64.StdEncoding.EncodeToString(b.Bytes())
+}
+
+// Decode a sequence of the given length from hexadecimal bytes, length < 1 takes all bits
+func BitSequenceFromHex(s string, length int) (*BitSequence, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return bitSequenceFromPadded(data, length)
+}
+
+// Decode a sequence of the given length from base64 bytes, length < 1 takes all bits
+func BitSequenceFromBase64(s string, length int) (*BitSequence, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return bitSequenceFromPadded(data, length)
+}
+
+func bitSequenceFromPadded(data []byte, length int) (*BitSequence, error) {
+	if len(data) == 0 {
+		return nil, fmt.Errorf("empty bit sequence")
+	}
+	if length < 1 {
+		length = 8 * len(data)
+	}
+	if length > 8*len(data) || length <= 8*(len(data)-1) {
+		return nil, fmt.Errorf("length %d does not match %d bytes", length, len(data))
+	}
+	return BitSequenceFromBytes(data).Slice(0, length), nil
+}
+
+// Parse a string of '0' and '1' characters as produced by String
+func BitSequenceFromString(s string) (*BitSequence, error) {
+	if len(s) == 0 {
+		return nil, fmt.Errorf("empty bit sequence")
+	}
+	seq := NewBitSequence(len(s))
+	for i := range len(s) {
+		switch s[i] {
+		case '0':
+		case '1':
+			seq.bits[i/64] |= 1 << (i % 64)
+		default:
+			return nil, fmt.Errorf("invalid bit %q at position %d", s[i], i)
+		}
+	}
+	return seq, nil
+}
+
+// OR src into the sequence starting at bit offset, bits falling past the last word are dropped
+func (b *BitSequence) orShifted(src *BitSequence, offset int) {
+	word, shift := offset/64, offset%64
+	for i, w := range src.bits {
+		if word+i >= len(b.bits) {
+			break
+		}
+		b.bits[word+i] |= w << shift
+		if shift != 0 && word+i+1 < len(b.bits) {
+			b.bits[word+i+1] |= w >> (64 - shift)
+		}
+	}
+}
+
+// Count the ones among bits start..end-1
+func (b *BitSequence) onesInRange(start, end int) int {
+	count := 0
+	for start < end {
+		shift := start % 64
+		n := min(64-shift, end-start)
+		word := b.bits[start/64] >> shift
+		if n < 64 {
+			word &= 1<<n - 1
+		}
+		count += bits.OnesCount64(word)
+		start += n
+	}
+	return count
+}
+
+// Zero the unused bits of the last word
+func (b *BitSequence) clearTail() {
+	if rem := b.length % 64; rem != 0 {
+		b.bits[len(b.bits)-1] &= 1<<rem - 1
+	}
+}
+
+func (b *BitSequence) checkSameLength(other *BitSequence) {
+	if b.length != other.length {
+		panic("Sequences must have the same length")
+	}
+}
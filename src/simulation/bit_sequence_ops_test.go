@@ -0,0 +1,132 @@
+package simulation
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// Per-bit reference implementations, the loops the word-level operations replaced
+
+func naiveXor(a, b *BitSequence) *BitSequence {
+	result := NewBitSequence(a.Len())
+	for i := range a.Len() {
+		result.Set(i, a.Get(i)^b.Get(i))
+	}
+	return result
+}
+
+func naiveHammingDistance(a, b *BitSequence) int {
+	distance := 0
+	for i := range a.Len() {
+		if a.Get(i) != b.Get(i) {
+			distance++
+		}
+	}
+	return distance
+}
+
+func naiveSlice(b *BitSequence, start, end int) *BitSequence {
+	result := NewBitSequence(end - start)
+	for i := start; i < end; i++ {
+		result.Set(i-start, b.Get(i))
+	}
+	return result
+}
+
+func naiveConcat(a, b *BitSequence) *BitSequence {
+	result := NewBitSequence(a.Len() + b.Len())
+	for i := range a.Len() {
+		result.Set(i, a.Get(i))
+	}
+	for i := range b.Len() {
+		result.Set(a.Len()+i, b.Get(i))
+	}
+	return result
+}
+
+func naiveRotate(b *BitSequence, k int) *BitSequence {
+	result := NewBitSequence(b.Len())
+	for i := range b.Len() {
+		result.Set(i, b.Get((i+k)%b.Len()))
+	}
+	return result
+}
+
+func TestWordOperationsMatchPerBit(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, length := range []int{1, 7, 63, 64, 65, 127, 128, 1000} {
+		a := RandomSequence(length, rng)
+		b := RandomSequence(length, rng)
+		other := RandomSequence(1+rng.Intn(130), rng)
+		start := rng.Intn(length)
+		end := start + 1 + rng.Intn(length-start)
+		k := rng.Intn(length)
+		checks := []struct {
+			name string
+			ok   bool
+		}{
+			{"Xor", a.Xor(b).Equal(naiveXor(a, b))},
+			{"HammingDistance", a.HammingDistance(b) == naiveHammingDistance(a, b)},
+			{"Slice", a.Slice(start, end).Equal(naiveSlice(a, start, end))},
+			{"Concat", Concat(a, other).Equal(naiveConcat(a, other))},
+			{"Rotate", a.Rotate(k).Equal(naiveRotate(a, k))},
+		}
+		for _, check := range checks {
+			if !check.ok {
+				t.Errorf("L=%d: %s differs from the per-bit reference", length, check.name)
+			}
+		}
+	}
+}
+
+var benchmarkLengths = []int{1023, 65535}
+
+// Runs the word-level and the per-bit variant of an operation on sequences of every benchmark length
+func benchmarkOperation(b *testing.B, words, perBit func(x, y *BitSequence)) {
+	for _, length := range benchmarkLengths {
+		rng := rand.New(rand.NewSource(1))
+		x := RandomSequence(length, rng)
+		y := RandomSequence(length, rng)
+		b.Run(fmt.Sprintf("words/L=%d", length), func(b *testing.B) {
+			for b.Loop() {
+				words(x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("per-bit/L=%d", length), func(b *testing.B) {
+			for b.Loop() {
+				perBit(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkXor(b *testing.B) {
+	benchmarkOperation(b,
+		func(x, y *BitSequence) { x.Xor(y) },
+		func(x, y *BitSequence) { naiveXor(x, y) })
+}
+
+func BenchmarkHammingDistance(b *testing.B) {
+	benchmarkOperation(b,
+		func(x, y *BitSequence) { x.HammingDistance(y) },
+		func(x, y *BitSequence) { naiveHammingDistance(x, y) })
+}
+
+func BenchmarkSlice(b *testing.B) {
+	benchmarkOperation(b,
+		func(x, _ *BitSequence) { x.Slice(3, x.Len()-5) },
+		func(x, _ *BitSequence) { naiveSlice(x, 3, x.Len()-5) })
+}
+
+func BenchmarkConcat(b *testing.B) {
+	benchmarkOperation(b,
+		func(x, y *BitSequence) { Concat(x, y) },
+		func(x, y *BitSequence) { naiveConcat(x, y) })
+}
+
+func BenchmarkRotate(b *testing.B) {
+	benchmarkOperation(b,
+		func(x, _ *BitSequence) { x.Rotate(37) },
+		func(x, _ *BitSequence) { naiveRotate(x, 37) })
+}
@@ -2,354 +2,501 @@ package simulation
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
+	"slices"
 	"strings"
 	"time"
 )
 
+// Configuration of a single CDMA user
+type CDMAUserConfig struct {
+	Seed1 uint64  // Initial state of LFSR1
+	Seed2 uint64  // Initial state of LFSR2
+	Text  string  // Payload, random bits are sent when empty
+	Power float64 // Linear transmit power, chip amplitude is sqrt(Power)
+
+	UseCodeIndex bool // Take the code from the code set instead of generating it from the seeds
+	CodeIndex    int  // Index into the code set, see CodeSet.Code
+
+	SpreadingFactor int // OVSF only: chips per data bit, a power of two up to 2^n, 0 uses 2^n
+	Delay           int // Chip offset of the signal at the receiver, the other users see it as a timing error
+}
+
+// Per-user part of a CDMA simulation result
+type CDMAUserResult struct {
+	Label     string
+	Seed1     uint64
+	Seed2     uint64
+	Power     float64
+	InputText string
+
+	UseCodeIndex    bool
+	CodeIndex       int
+	CodeName        string
+	SpreadingFactor int // Chips per data bit
+	Delay           int
+
+	OriginalDataSeq *BitSequence
+	EncodedDataSeq  *BitSequence
+	DecodedDataSeq  *BitSequence
+
+	GoldCode                  *BitSequence
+	GoldCodeStr               string
+	MaxOffPeakAutocorrelation float32
+
+	TransmittedSignalStr     string
+	ReceivedSignalSegmentStr string
+	CorrelatedSignalStr      string
+
+	BER           float32
+	ErrorCount    int
+	DecodedText   string
+	DataBitLength int
+	MAI           float32 // RMS multiple access interference relative to the user's own correlation peak
+}
+
 type CDMAResult struct {
 	N                  uint
 	Poly1              []uint
 	Poly2              []uint
-	SeedA1             uint64
-	SeedA2             uint64
-	SeedB1             uint64
-	SeedB2             uint64
+	Family             string // Code family, see CodeFamilyGold
 	NoiseLevel         float64
-	InputTextA         string
-	InputTextB         string
 	SeqLengthForRandom int
+	Seed               int64 // Master seed of the data and noise generators
 
-	OriginalDataSeqA *BitSequence
-	OriginalDataSeqB *BitSequence
-	EncodedDataSeqA  *BitSequence
-	EncodedDataSeqB  *BitSequence
-	DecodedDataSeqA  *BitSequence
-	DecodedDataSeqB  *BitSequence
-
-	GoldCodeA          *BitSequence
-	GoldCodeB          *BitSequence
-	GoldCodeAStr       string
-	GoldCodeBStr       string
-	CrossCorrelationAB float32
+	Users []CDMAUserResult
 
-	AutocorrelationPeak        int
-	MaxOffPeakAutocorrelationA float32
-	MaxOffPeakAutocorrelationB float32
+	// CrossCorrelation[i][j] is the normalized cross-correlation of the codes of users i and j at their relative delay
+	CrossCorrelation    [][]float32
+	MaxCrossCorrelation float32
+	AutocorrelationPeak int
 
 	CombinedSignalStr string
 	ReceivedSignalStr string
 
-	ReceivedSignalSegmentAStr string
-	ReceivedSignalSegmentBStr string
-
-	CorrelatedSignalUserAStr string
-	CorrelatedSignalUserBStr string
-
-	BER_A        float32
-	ErrorCountA  int
-	BER_B        float32
-	ErrorCountB  int
-	DecodedTextA string
-	DecodedTextB string
-
-	DataBitLengthUserA   int
-	DataBitLengthUserB   int
-	SimulationDataLength int
-	GoldCodeLength       int
-	Timestamp            string
-
-	TransmittedSignalAStr       string
-	TransmittedSignalBStr       string
+	SimulationDataLength        int // Bits of the users with the longest code
+	GoldCodeLength              int // Length of the longest code
+	Timestamp                   string
 	FullTransmittedSignalLength int
 }
 
-func SimulateCDMA(n uint, poly1 []uint, poly2 []uint,
-	seedA1, seedA2 uint64, textA string,
-	seedB1, seedB2 uint64, textB string,
-	seqLengthForRandomBits int, noiseLevel float64) *CDMAResult {
+// Returns the display label of the user with the given index ("A", "B", ..., then "U27", "U28", ...)
+func UserLabel(index int) string {
+	if index >= 0 && index < 26 {
+		return string(rune('A' + index))
+	}
+	return fmt.Sprintf("U%d", index+1)
+}
+
+// Simulates a CDMA link shared by len(users) users, each spread with its own code of the family.
+// Users are chip-synchronous unless they are given a delay. Random data and channel noise are drawn
+// from streams derived from seed, so equal seeds give equal results.
+func SimulateCDMA(n uint, poly1 []uint, poly2 []uint, family string, users []CDMAUserConfig,
+	seqLengthForRandomBits int, noiseLevel float64, seed int64) *CDMAResult {
+
+	link := NewCDMALink(n, poly1, poly2, family, users)
+	users = link.Users
+	numUsers := len(users)
+	goldCodes := link.Codes
+	goldCodeLength := link.CodeLength
+	autocorrPeak := goldCodeLength
 
-	if seedA1 == seedB1 && seedA2 == seedB2 {
-		if seedB2 > 1 {
-			seedB2--
-		} else {
-			seedB2++
-		}
-		if seedA1 == seedB1 && seedA2 == seedB2 {
-			seedB1++
+	crossCorr := link.CrossCorrelation()
+	maxCrossCorr := float32(0)
+	for i := range numUsers {
+		for j := range numUsers {
+			if i != j && float32(math.Abs(float64(crossCorr[i][j]))) > maxCrossCorr {
+				maxCrossCorr = float32(math.Abs(float64(crossCorr[i][j])))
+			}
 		}
 	}
 
-	goldCodeA := GenerateGoldCode(n, poly1, seedA1, poly2, seedA2)
-	goldCodeB := GenerateGoldCode(n, poly1, seedB1, poly2, seedB2)
-	goldCodeLength := goldCodeA.Len()
+	dataRand := rand.New(rand.NewSource(DeriveSeed(seed, 0)))
+	noiseRand := rand.New(rand.NewSource(DeriveSeed(seed, 1)))
 
-	signalCodeA := BitsToSignal(*goldCodeA)
-	signalCodeB := BitsToSignal(*goldCodeB)
-	autocorrPeak := goldCodeLength
-	maxOffPeakAutoA := MaxAbsoluteOffPeak(CalculatePeriodicAutocorrelation(*goldCodeA))
-	maxOffPeakAutoB := MaxAbsoluteOffPeak(CalculatePeriodicAutocorrelation(*goldCodeB))
-	crossCorrAB_normalized := CalculateNormalizedCrossCorrelation(signalCodeA, signalCodeB)
-
-	var dataSeqA, dataSeqB *BitSequence
-	inputIsTextA := textA != ""
-	inputIsTextB := textB != ""
-
-	if inputIsTextA {
-		dataSeqA = StringAsSequence(textA)
-	} else {
-		dataSeqA = RandomSequence(seqLengthForRandomBits)
-	}
-	if inputIsTextB {
-		dataSeqB = StringAsSequence(textB)
-	} else {
-		dataSeqB = RandomSequence(seqLengthForRandomBits)
+	dataSeqs := make([]*BitSequence, numUsers)
+	for u, user := range users {
+		if user.Text != "" {
+			dataSeqs[u] = StringAsSequence(user.Text)
+		} else {
+			dataSeqs[u] = RandomSequence(seqLengthForRandomBits, dataRand)
+		}
 	}
 
-	dataLenA := dataSeqA.Len()
-	dataLenB := dataSeqB.Len()
-
-	simulationDataLen := dataLenA
-	if dataLenB > simulationDataLen {
-		simulationDataLen = dataLenB
+	// All users transmit for the same whole number of periods of the longest code,
+	// users with shorter codes send proportionally more (padding) bits
+	frameChips := goldCodeLength
+	for u := range users {
+		frameChips = max(frameChips, dataSeqs[u].Len()*link.SpreadingFactor(u))
 	}
-	if simulationDataLen == 0 {
-		simulationDataLen = 1
-		if dataLenA == 0 {
-			dataSeqA = RandomSequence(1)
-			dataLenA = 1
+	frameChips = (frameChips + goldCodeLength - 1) / goldCodeLength * goldCodeLength
+	simulationDataLen := frameChips / goldCodeLength
+
+	totalSignalLength := frameChips + link.MaxDelay()
+	combinedSignal := make([]float32, totalSignalLength)
+	transmittedSignals := make([][]float32, numUsers)
+	encodedSeqs := make([]*BitSequence, numUsers)
+	symbols := make([][]float32, numUsers)
+
+	for u := range users {
+		paddedData := NewBitSequence(frameChips / link.SpreadingFactor(u))
+		copy(paddedData.bits, dataSeqs[u].bits)
+		encodedSeqs[u] = EncodeWithGold(*paddedData, *goldCodes[u])
+
+		amplitude := link.amplitudes[u]
+		symbols[u] = make([]float32, paddedData.Len())
+		for i := range symbols[u] {
+			symbols[u][i] = amplitude
+			if paddedData.Get(i) == 0 {
+				symbols[u][i] = -amplitude
+			}
 		}
-		if dataLenB == 0 {
-			dataSeqB = RandomSequence(1)
-			dataLenB = 1
+		transmitted := make([]float32, totalSignalLength)
+		link.spread(u, symbols[u], transmitted)
+		for i := range transmitted {
+			combinedSignal[i] += transmitted[i]
 		}
+		transmittedSignals[u] = transmitted
 	}
 
-	paddedDataA := NewBitSequence(simulationDataLen)
-	paddedDataB := NewBitSequence(simulationDataLen)
-	for i := 0; i < simulationDataLen; i++ {
-		if i < dataLenA {
-			paddedDataA.Set(i, dataSeqA.Get(i))
-		}
-		if i < dataLenB {
-			paddedDataB.Set(i, dataSeqB.Get(i))
-		}
+	receivedSignal := make([]float32, totalSignalLength)
+	for i := 0; i < totalSignalLength; i++ {
+		noise := noiseRand.NormFloat64() * noiseLevel
+		receivedSignal[i] = combinedSignal[i] + float32(noise)
 	}
 
-	encodedDataA := EncodeWithGold(*paddedDataA, *goldCodeA)
-	encodedDataB := EncodeWithGold(*paddedDataB, *goldCodeB)
+	displayLimit := 40
+	displayLimitSignalSegment := 40
+	displayLimitCorrelationSums := 20
 
-	transmittedSignalA := make([]float32, simulationDataLen*goldCodeLength)
-	transmittedSignalB := make([]float32, simulationDataLen*goldCodeLength)
+	userResults := make([]CDMAUserResult, numUsers)
+	for u, user := range users {
+		dataSeq := dataSeqs[u]
+		dataLen := dataSeq.Len()
 
-	for i := 0; i < simulationDataLen; i++ {
-		dataBitA := float32(1)
-		if paddedDataA.Get(i) == 0 {
-			dataBitA = -1
+		spreadingFactor := link.SpreadingFactor(u)
+		corrSums := link.despread(u, receivedSignal, len(symbols[u]))
+		receivedBits := NewBitSequence(len(corrSums))
+		for i, corrSum := range corrSums {
+			if corrSum > 0 {
+				receivedBits.Set(i, 1)
+			}
 		}
-		dataBitB := float32(1)
-		if paddedDataB.Get(i) == 0 {
-			dataBitB = -1
+		finalDecoded := receivedBits
+		if finalDecoded.Len() > dataLen {
+			finalDecoded = finalDecoded.Slice(0, dataLen)
 		}
 
-		for j := 0; j < goldCodeLength; j++ {
-			chipA := float32(1)
-			if goldCodeA.Get(j) == 0 {
-				chipA = -1
-			}
-			chipB := float32(1)
-			if goldCodeB.Get(j) == 0 {
-				chipB = -1
-			}
+		// Multiple access interference: what the other users leave at the correlator output of the
+		// noise-free signal, relative to the user's own correlation peak. Zero for orthogonal codes.
+		interference := link.despread(u, combinedSignal, dataLen)
+		maiPower := 0.0
+		for i := range dataLen {
+			mai := float64(interference[i] - symbols[u][i]*float32(spreadingFactor))
+			maiPower += mai * mai
+		}
+		mai := float32(math.Sqrt(maiPower/float64(dataLen)) / (float64(link.amplitudes[u]) * float64(spreadingFactor)))
+
+		ber := CalculateBER(*dataSeq, *finalDecoded)
+		errCount := dataSeq.HammingDistance(finalDecoded)
 
-			transmittedSignalA[i*goldCodeLength+j] = dataBitA * chipA
-			transmittedSignalB[i*goldCodeLength+j] = dataBitB * chipB
+		decodedText := ""
+		if user.Text != "" && finalDecoded.Len()%8 == 0 {
+			decodedText = BitsToASCII(finalDecoded.String())
 		}
-	}
 
-	totalSignalLength := len(transmittedSignalA)
-	combinedSignal := make([]float32, totalSignalLength)
-	for i := 0; i < totalSignalLength; i++ {
-		combinedSignal[i] = transmittedSignalA[i] + transmittedSignalB[i]
+		delay := users[u].Delay
+		endIndex := min(delay+dataLen*spreadingFactor, len(receivedSignal))
+
+		userResults[u] = CDMAUserResult{
+			Label:                     UserLabel(u),
+			Seed1:                     user.Seed1,
+			Seed2:                     user.Seed2,
+			Power:                     link.Power(u),
+			InputText:                 user.Text,
+			UseCodeIndex:              user.UseCodeIndex,
+			CodeIndex:                 user.CodeIndex,
+			CodeName:                  link.CodeName(u),
+			SpreadingFactor:           spreadingFactor,
+			Delay:                     delay,
+			MAI:                       mai,
+			OriginalDataSeq:           dataSeq,
+			EncodedDataSeq:            encodedSeqs[u],
+			DecodedDataSeq:            finalDecoded,
+			GoldCode:                  goldCodes[u],
+			GoldCodeStr:               goldCodes[u].String(),
+			MaxOffPeakAutocorrelation: MaxAbsoluteOffPeak(CalculatePeriodicAutocorrelation(*goldCodes[u])),
+			TransmittedSignalStr:      floatSignalToString(transmittedSignals[u], displayLimit),
+			ReceivedSignalSegmentStr:  floatSignalToString(receivedSignal[delay:endIndex], displayLimitSignalSegment),
+			CorrelatedSignalStr:       floatSignalToString(corrSums[:dataLen], displayLimitCorrelationSums),
+			BER:                       ber,
+			ErrorCount:                errCount,
+			DecodedText:               decodedText,
+			DataBitLength:             dataLen,
+		}
 	}
 
-	receivedSignal := make([]float32, totalSignalLength)
-	noiseRandSource := rand.NewSource(time.Now().UnixNano())
-	noiseRand := rand.New(noiseRandSource)
-	for i := 0; i < totalSignalLength; i++ {
-		noise := noiseRand.NormFloat64() * noiseLevel
-		receivedSignal[i] = combinedSignal[i] + float32(noise)
+	return &CDMAResult{
+		N:                           n,
+		Poly1:                       poly1,
+		Poly2:                       poly2,
+		Family:                      family,
+		NoiseLevel:                  noiseLevel,
+		SeqLengthForRandom:          seqLengthForRandomBits,
+		Seed:                        seed,
+		Users:                       userResults,
+		CrossCorrelation:            crossCorr,
+		MaxCrossCorrelation:         maxCrossCorr,
+		AutocorrelationPeak:         autocorrPeak,
+		CombinedSignalStr:           floatSignalToString(combinedSignal, displayLimit),
+		ReceivedSignalStr:           floatSignalToString(receivedSignal, displayLimit),
+		SimulationDataLength:        simulationDataLen,
+		GoldCodeLength:              goldCodeLength,
+		Timestamp:                   time.Now().Format(time.RFC1123),
+		FullTransmittedSignalLength: totalSignalLength,
 	}
+}
 
-	receivedBitsA, corrSumsA_full := signalToBitsCorrelation(receivedSignal, signalCodeA, goldCodeLength, simulationDataLen)
-	receivedBitsB, corrSumsB_full := signalToBitsCorrelation(receivedSignal, signalCodeB, goldCodeLength, simulationDataLen)
+// Spreading codes and amplitudes of a CDMA link, built once and reused across trials
+type CDMALink struct {
+	Users      []CDMAUserConfig // User configurations after seed and code index collisions were resolved
+	Codes      []*BitSequence   // Spreading code of every user
+	CodeLength int              // Length of the longest code, one frame bit of the slowest user
 
-	finalDecodedA := receivedBitsA
-	finalDecodedB := receivedBitsB
+	codeNames   []string    // Display name of every code, empty for codes generated from seeds
+	signalCodes [][]float32 // Codes as +1/-1 chips
+	amplitudes  []float32   // Chip amplitude of every user
+}
 
-	if finalDecodedA.Len() > dataLenA {
-		trimmedDecodedA := NewBitSequence(dataLenA)
-		for i := range dataLenA {
-			trimmedDecodedA.Set(i, finalDecodedA.Get(i))
+// Generates the spreading codes of all users sharing the link. Families without seeds give
+// users without a code index the code with their own position as index. OVSF users get a code
+// of their spreading factor that is orthogonal to the codes of the users before them, if one is left.
+func NewCDMALink(n uint, poly1 []uint, poly2 []uint, family string, users []CDMAUserConfig) *CDMALink {
+	if len(users) == 0 {
+		panic("CDMA simulation requires at least one user")
+	}
+	users = uniqueCodeAssignments(users, n)
+	if !CodeFamilyUsesSeeds(family) {
+		size := CodeFamilySize(family, n)
+		for u := range users {
+			if !users[u].UseCodeIndex {
+				users[u].UseCodeIndex = true
+				users[u].CodeIndex = u % size
+			}
 		}
-		finalDecodedA = trimmedDecodedA
 	}
-
-	if finalDecodedB.Len() > dataLenB {
-		trimmedDecodedB := NewBitSequence(dataLenB)
-		for i := range dataLenB {
-			trimmedDecodedB.Set(i, finalDecodedB.Get(i))
+	link := &CDMALink{
+		Users:       users,
+		Codes:       make([]*BitSequence, len(users)),
+		codeNames:   make([]string, len(users)),
+		signalCodes: make([][]float32, len(users)),
+		amplitudes:  make([]float32, len(users)),
+	}
+	// Seed based codes first, indexed codes then skip indexes whose code is already taken
+	for u, user := range users {
+		users[u].Delay = max(user.Delay, 0)
+		if !user.UseCodeIndex {
+			link.Codes[u] = NewGoldCode(n, poly1, user.Seed1, poly2, user.Seed2).Chips()
 		}
-		finalDecodedB = trimmedDecodedB
 	}
-
-	var berA, berB float32
-	var errCountA, errCountB int
-
-	if dataLenA > 0 {
-		berA = CalculateBER(*dataSeqA, *finalDecodedA)
-		for i := 0; i < dataLenA; i++ {
-			if dataSeqA.Get(i) != finalDecodedA.Get(i) {
-				errCountA++
+	var codeSet CodeSet
+	for u := range users {
+		if !users[u].UseCodeIndex {
+			continue
+		}
+		if codeSet == nil {
+			codeSet = NewCodeSet(family, n, poly1, poly2)
+		}
+		if tree, ok := codeSet.(*OVSFTree); ok {
+			link.assignOVSFCode(tree, u)
+			continue
+		}
+		size := codeSet.Size()
+		for range size {
+			link.Codes[u] = codeSet.Code(users[u].CodeIndex)
+			if !codeTaken(link.Codes, u) {
+				break
 			}
+			users[u].CodeIndex = (users[u].CodeIndex + 1) % size
 		}
+		link.codeNames[u] = codeSet.Name(users[u].CodeIndex)
 	}
+	for u := range users {
+		link.signalCodes[u] = BitsToSignal(*link.Codes[u])
+		link.amplitudes[u] = float32(math.Sqrt(link.Power(u)))
+		link.CodeLength = max(link.CodeLength, link.Codes[u].Len())
+	}
+	return link
+}
 
-	if dataLenB > 0 {
-		berB = CalculateBER(*dataSeqB, *finalDecodedB)
-		for i := 0; i < dataLenB; i++ {
-			if dataSeqB.Get(i) != finalDecodedB.Get(i) {
-				errCountB++
-			}
+// Assigns user u the first code of its spreading factor, starting from its code index, that is
+// orthogonal to the codes of the users before it. Keeps the requested code if none is.
+func (l *CDMALink) assignOVSFCode(tree *OVSFTree, u int) {
+	user := &l.Users[u]
+	user.SpreadingFactor = tree.SpreadingFactor(user.SpreadingFactor)
+	user.CodeIndex %= user.SpreadingFactor
+	for range user.SpreadingFactor {
+		if !l.ovsfConflict(u) {
+			break
 		}
+		user.CodeIndex = (user.CodeIndex + 1) % user.SpreadingFactor
 	}
+	l.Codes[u] = OVSFCode(user.SpreadingFactor, user.CodeIndex)
+	l.codeNames[u] = OVSFName(user.SpreadingFactor, user.CodeIndex)
+}
 
-	decodedTextA := ""
-	if inputIsTextA && finalDecodedA.Len() > 0 && finalDecodedA.Len()%8 == 0 {
-		decodedTextA = BitsToASCII(finalDecodedA.String())
+// Reports whether the OVSF code of user u conflicts with the code of any user before it
+func (l *CDMALink) ovsfConflict(u int) bool {
+	user := l.Users[u]
+	for _, other := range l.Users[:u] {
+		if OVSFConflict(other.SpreadingFactor, other.CodeIndex, user.SpreadingFactor, user.CodeIndex) {
+			return true
+		}
 	}
-	decodedTextB := ""
-	if inputIsTextB && finalDecodedB.Len() > 0 && finalDecodedB.Len()%8 == 0 {
-		decodedTextB = BitsToASCII(finalDecodedB.String())
+	return false
+}
+
+// Returns the display name of the code of the given user, empty for codes generated from seeds
+func (l *CDMALink) CodeName(user int) string {
+	return l.codeNames[user]
+}
+
+// Returns the number of chips per data bit of the given user, the length of its code
+func (l *CDMALink) SpreadingFactor(user int) int {
+	return len(l.signalCodes[user])
+}
+
+// Returns the largest chip delay of any user
+func (l *CDMALink) MaxDelay() int {
+	maxDelay := 0
+	for _, user := range l.Users {
+		maxDelay = max(maxDelay, user.Delay)
 	}
+	return maxDelay
+}
 
-	displayLimit := 40
-	displayLimitSignalSegment := 40
-	displayLimitCorrelationSums := 20
+// Returns the number of bits the given user sends while a user with the longest code sends dataBits
+func (l *CDMALink) BitsPerTrial(user int, dataBits int) int {
+	return dataBits * l.CodeLength / l.SpreadingFactor(user)
+}
 
-	var receivedSignalSegmentAStr string
-	if dataLenA > 0 {
-		endIndexA := dataLenA * goldCodeLength
-		if endIndexA > len(receivedSignal) {
-			endIndexA = len(receivedSignal)
-		}
-		if endIndexA > 0 {
-			receivedSignalSegmentAStr = floatSignalToString(receivedSignal[:endIndexA], displayLimitSignalSegment)
+// Returns the normalized cross-correlation matrix of the user codes at their relative delays,
+// over one period of the longest code. Off-diagonal entries are zero for orthogonal codes.
+func (l *CDMALink) CrossCorrelation() [][]float32 {
+	matrix := make([][]float32, len(l.Users))
+	for i := range l.Users {
+		matrix[i] = make([]float32, len(l.Users))
+		for j := range l.Users {
+			shift := l.Users[j].Delay - l.Users[i].Delay
+			matrix[i][j] = CalculatePeriodicCrossCorrelation(l.signalCodes[i], l.signalCodes[j], shift)
 		}
 	}
+	return matrix
+}
 
-	var receivedSignalSegmentBStr string
-	if dataLenB > 0 {
-		endIndexB := dataLenB * goldCodeLength
-		if endIndexB > len(receivedSignal) {
-			endIndexB = len(receivedSignal)
-		}
-		if endIndexB > 0 {
-			receivedSignalSegmentBStr = floatSignalToString(receivedSignal[:endIndexB], displayLimitSignalSegment)
+// Returns the transmit power of the given user, non-positive powers default to 1
+func (l *CDMALink) Power(user int) float64 {
+	if l.Users[user].Power <= 0 {
+		return 1
+	}
+	return l.Users[user].Power
+}
+
+// Adds the given data symbols of a user, spread with its code, to signal starting at the user's delay
+func (l *CDMALink) spread(user int, symbols []float32, signal []float32) {
+	code := l.signalCodes[user]
+	start := l.Users[user].Delay
+	for i, symbol := range symbols {
+		for j, chip := range code {
+			signal[start+i*len(code)+j] += symbol * chip
 		}
 	}
+}
 
-	var corrSumsA, corrSumsB []float32
-	var correlatedSignalUserAStr, correlatedSignalUserBStr string
+// Correlates bitCount code periods of signal, starting at the user's delay, with the user's code
+func (l *CDMALink) despread(user int, signal []float32, bitCount int) []float32 {
+	code := l.signalCodes[user]
+	start := l.Users[user].Delay
+	sums := make([]float32, bitCount)
+	for i := range sums {
+		segment := signal[start+i*len(code) : start+(i+1)*len(code)]
+		sums[i] = CalculateCorrelationSum(segment, code)
+	}
+	return sums
+}
+
+// Sends random bits over the link with additive gaussian noise of standard deviation noiseSigma
+// and returns the number of bit errors of every user. Users with the longest code send dataBits
+// bits, the others as many as fit in the same chips, see BitsPerTrial.
+func (l *CDMALink) RunTrial(dataBits int, noiseSigma float64, rng *rand.Rand) []int {
+	numUsers := len(l.Users)
+	data := make([][]float32, numUsers)
+	for u := range numUsers {
+		data[u] = make([]float32, l.BitsPerTrial(u, dataBits))
+		for i := range data[u] {
+			if rng.Intn(2) == 1 {
+				data[u][i] = l.amplitudes[u]
+			} else {
+				data[u][i] = -l.amplitudes[u]
+			}
+		}
+	}
 
-	if dataLenA > 0 && len(corrSumsA_full) >= dataLenA {
-		corrSumsA = corrSumsA_full[:dataLenA]
-		correlatedSignalUserAStr = floatSignalToString(corrSumsA, displayLimitCorrelationSums)
+	received := make([]float32, dataBits*l.CodeLength+l.MaxDelay())
+	for u := range numUsers {
+		l.spread(u, data[u], received)
 	}
-	if dataLenB > 0 && len(corrSumsB_full) >= dataLenB {
-		corrSumsB = corrSumsB_full[:dataLenB]
-		correlatedSignalUserBStr = floatSignalToString(corrSumsB, displayLimitCorrelationSums)
+	for j := range received {
+		received[j] += float32(rng.NormFloat64() * noiseSigma)
 	}
 
-	return &CDMAResult{
-		N:                           n,
-		Poly1:                       poly1,
-		Poly2:                       poly2,
-		SeedA1:                      seedA1,
-		SeedA2:                      seedA2,
-		SeedB1:                      seedB1,
-		SeedB2:                      seedB2,
-		NoiseLevel:                  noiseLevel,
-		InputTextA:                  textA,
-		InputTextB:                  textB,
-		SeqLengthForRandom:          seqLengthForRandomBits,
-		OriginalDataSeqA:            dataSeqA,
-		OriginalDataSeqB:            dataSeqB,
-		EncodedDataSeqA:             encodedDataA,
-		EncodedDataSeqB:             encodedDataB,
-		DecodedDataSeqA:             finalDecodedA,
-		DecodedDataSeqB:             finalDecodedB,
-		GoldCodeA:                   goldCodeA,
-		GoldCodeB:                   goldCodeB,
-		GoldCodeAStr:                goldCodeA.String(),
-		GoldCodeBStr:                goldCodeB.String(),
-		CrossCorrelationAB:          crossCorrAB_normalized,
-		AutocorrelationPeak:         autocorrPeak,
-		MaxOffPeakAutocorrelationA:  maxOffPeakAutoA,
-		MaxOffPeakAutocorrelationB:  maxOffPeakAutoB,
-		TransmittedSignalAStr:       floatSignalToString(transmittedSignalA, displayLimit),
-		TransmittedSignalBStr:       floatSignalToString(transmittedSignalB, displayLimit),
-		CombinedSignalStr:           floatSignalToString(combinedSignal, displayLimit),
-		ReceivedSignalStr:           floatSignalToString(receivedSignal, displayLimit),
-		ReceivedSignalSegmentAStr:   receivedSignalSegmentAStr,
-		ReceivedSignalSegmentBStr:   receivedSignalSegmentBStr,
-		CorrelatedSignalUserAStr:    correlatedSignalUserAStr,
-		CorrelatedSignalUserBStr:    correlatedSignalUserBStr,
-		BER_A:                       berA,
-		ErrorCountA:                 errCountA,
-		BER_B:                       berB,
-		ErrorCountB:                 errCountB,
-		DecodedTextA:                decodedTextA,
-		DecodedTextB:                decodedTextB,
-		DataBitLengthUserA:          dataLenA,
-		DataBitLengthUserB:          dataLenB,
-		SimulationDataLength:        simulationDataLen,
-		GoldCodeLength:              goldCodeLength,
-		Timestamp:                   time.Now().Format(time.RFC1123),
-		FullTransmittedSignalLength: simulationDataLen * goldCodeLength,
+	errors := make([]int, numUsers)
+	for u := range numUsers {
+		for i, corrSum := range l.despread(u, received, len(data[u])) {
+			if (corrSum > 0) != (data[u][i] > 0) {
+				errors[u]++
+			}
+		}
 	}
+	return errors
 }
 
-func signalToBitsCorrelation(receivedSignal []float32, goldCodeSignal []float32, goldCodeLength int, dataBits int) (*BitSequence, []float32) {
-	result := NewBitSequence(dataBits)
-	correlationSums := make([]float32, dataBits)
-
-	for i := 0; i < dataBits; i++ {
-		segmentStart := i * goldCodeLength
-		segmentEnd := (i + 1) * goldCodeLength
-		if segmentEnd > len(receivedSignal) {
-			segmentEnd = len(receivedSignal)
-		}
-		if segmentStart >= segmentEnd {
+// Returns a copy of users where every seed pair is distinct, nudging later duplicates so that
+// no two users end up with the same gold code. Users taking an indexed code are left as they are.
+func uniqueCodeAssignments(users []CDMAUserConfig, n uint) []CDMAUserConfig {
+	maxSeed := uint64(pow2(n) - 1)
+	unique := make([]CDMAUserConfig, len(users))
+	copy(unique, users)
+	seen := make(map[[2]uint64]bool, len(users))
+	for i := range unique {
+		if unique[i].UseCodeIndex {
 			continue
 		}
-
-		receivedSegment := receivedSignal[segmentStart:segmentEnd]
-
-		if len(receivedSegment) == goldCodeLength {
-			corrSum := CalculateCorrelationSum(receivedSegment, goldCodeSignal)
-			correlationSums[i] = corrSum
-			if corrSum > 0 {
-				result.Set(i, 1)
+		for seen[[2]uint64{unique[i].Seed1, unique[i].Seed2}] {
+			if unique[i].Seed2 > 1 {
+				unique[i].Seed2--
 			} else {
-				result.Set(i, 0)
+				unique[i].Seed2 = maxSeed
+				unique[i].Seed1 = unique[i].Seed1%maxSeed + 1
 			}
-		} else {
-			correlationSums[i] = 0.0
 		}
+		seen[[2]uint64{unique[i].Seed1, unique[i].Seed2}] = true
 	}
+	return unique
+}
 
-	return result, correlationSums
+// Reports whether the code of user u equals the code of any other user already assigned one
+func codeTaken(codes []*BitSequence, u int) bool {
+	for other, code := range codes {
+		if other == u || code == nil {
+			continue
+		}
+		if code.Len() == codes[u].Len() && slices.Equal(code.bits, codes[u].bits) {
+			return true
+		}
+	}
+	return false
 }
 
 func floatSignalToString(signal []float32, limit int) string {
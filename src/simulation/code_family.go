@@ -0,0 +1,145 @@
+package simulation
+
+// Spreading code families selectable in the general pipeline and the CDMA link
+const (
+	CodeFamilyGold        = "gold"         // XOR of the m-sequences of LFSR1 and LFSR2
+	CodeFamilyKasamiSmall = "kasami-small" // Small Kasami set of LFSR1, n even
+	CodeFamilyKasamiLarge = "kasami-large" // Large Kasami set of LFSR1, n ≡ 2 (mod 4)
+	CodeFamilyWalsh       = "walsh"        // Walsh-Hadamard codes of length 2^n
+	CodeFamilyOVSF        = "ovsf"         // OVSF code tree, spreading factors up to 2^n
+	CodeFamilyMSequence   = "msequence"    // Cyclic shifts of the m-sequence of LFSR1
+	CodeFamilyBarker      = "barker"       // Cyclic shifts of the longest Barker code of at most 2^n - 1 chips
+	CodeFamilyJPL         = "jpl"          // Cyclic shifts of the JPL code of LFSR1 and a catalog register, n <= 12
+)
+
+// Largest register length of the JPL family, its period grows with the product of the component periods
+const maxJPLRegister = 12
+
+// Indexed set of equally long spreading codes
+type CodeSet interface {
+	Size() int
+	Code(index int) *BitSequence
+	Name(index int) string
+}
+
+// Report whether codes of the family are built from the register seeds of every user.
+// The other families only have a fixed number of codes, which are always selected by index.
+func CodeFamilyUsesSeeds(family string) bool {
+	return family == "" || family == CodeFamilyGold
+}
+
+// Report whether the codes of the family are orthogonal, i.e. have zero cross-correlation
+// between chip-synchronous users, instead of being pseudo-random
+func CodeFamilyOrthogonal(family string) bool {
+	return family == CodeFamilyWalsh || family == CodeFamilyOVSF
+}
+
+// Report whether the codes of the family are built from LFSR1 (and LFSR2 if it uses seeds)
+func CodeFamilyUsesLFSR1(family string) bool {
+	switch family {
+	case CodeFamilyWalsh, CodeFamilyOVSF, CodeFamilyBarker:
+		return false
+	}
+	return true
+}
+
+// Report whether the code family can be built from n-bit registers (2^n chips for orthogonal codes)
+func CodeFamilySupported(family string, n uint) bool {
+	switch family {
+	case "", CodeFamilyGold, CodeFamilyWalsh, CodeFamilyOVSF, CodeFamilyMSequence, CodeFamilyBarker:
+		return n >= 2
+	case CodeFamilyJPL:
+		return n >= 2 && n <= maxJPLRegister
+	case CodeFamilyKasamiSmall:
+		return n >= 2 && n%2 == 0
+	case CodeFamilyKasamiLarge:
+		return n >= 2 && n%4 == 2
+	}
+	return false
+}
+
+// Return the number of codes of the family for n-bit registers, 0 if it is not supported.
+// For OVSF trees it is the number of codes of the largest spreading factor.
+func CodeFamilySize(family string, n uint) int {
+	if !CodeFamilySupported(family, n) {
+		return 0
+	}
+	switch family {
+	case CodeFamilyKasamiSmall:
+		return pow2(n / 2)
+	case CodeFamilyKasamiLarge:
+		return pow2(n/2) * (pow2(n) + 1)
+	case CodeFamilyWalsh, CodeFamilyOVSF:
+		return pow2(n)
+	case CodeFamilyMSequence, CodeFamilyBarker, CodeFamilyJPL:
+		return CodeFamilyLength(family, n)
+	}
+	return pow2(n) + 1
+}
+
+// Return the length of the codes of the family for n-bit registers, the longest OVSF spreading factor
+func CodeFamilyLength(family string, n uint) int {
+	switch family {
+	case CodeFamilyWalsh, CodeFamilyOVSF:
+		return pow2(n)
+	case CodeFamilyBarker:
+		return BarkerFamilyLength(n)
+	case CodeFamilyJPL:
+		return (pow2(n) - 1) * (pow2(JPLComponentRegister(n)) - 1)
+	}
+	return pow2(n) - 1
+}
+
+// Return the length of the Barker code used for n-bit registers, the longest of at most 2^n - 1 chips
+func BarkerFamilyLength(n uint) int {
+	maxLength := pow2(n) - 1
+	length := 2
+	for _, l := range BarkerLengths() {
+		if l <= maxLength {
+			length = l
+		}
+	}
+	return length
+}
+
+// Return the length of the second JPL register for an n-bit LFSR1, the smallest one whose
+// m-sequence period is coprime with 2^n - 1 (gcd(2^a - 1, 2^b - 1) = 2^gcd(a,b) - 1)
+func JPLComponentRegister(n uint) uint {
+	m := uint(2)
+	for gcd(int(m), int(n)) != 1 {
+		m++
+	}
+	return m
+}
+
+// Create the JPL code of the family: the m-sequence of LFSR1 XOR the catalog m-sequence of
+// the register chosen by JPLComponentRegister. It is a two-component XOR simplification, not a
+// JPL ranging code, whose components are combined by majority vote.
+func NewJPLFamilyCode(n uint, taps []uint) *JPLCode {
+	m := JPLComponentRegister(n)
+	return NewJPLCode(NewMSequenceCode(n, taps, 1), NewMSequenceCode(m, PrimitiveTaps(m), 1))
+}
+
+// Create the code set of the family. Gold codes use both registers, Kasami sets, m-sequences
+// and JPL codes only LFSR1, and the orthogonal families and Barker codes no register at all.
+func NewCodeSet(family string, n uint, taps1, taps2 []uint) CodeSet {
+	switch family {
+	case "", CodeFamilyGold:
+		return NewGoldFamily(n, taps1, taps2)
+	case CodeFamilyKasamiSmall:
+		return NewKasamiSet(n, taps1, false)
+	case CodeFamilyKasamiLarge:
+		return NewKasamiSet(n, taps1, true)
+	case CodeFamilyWalsh:
+		return NewWalshSet(n)
+	case CodeFamilyOVSF:
+		return NewOVSFTree(n)
+	case CodeFamilyMSequence:
+		return NewShiftSet(NewMSequenceCode(n, taps1, 1))
+	case CodeFamilyBarker:
+		return NewShiftSet(NewBarkerCode(BarkerFamilyLength(n)))
+	case CodeFamilyJPL:
+		return NewShiftSet(NewJPLFamilyCode(n, taps1))
+	}
+	panic("Unknown code family " + family)
+}
@@ -1,28 +1,23 @@
 package simulation
 
-import "math"
+import (
+	"math"
+	"sort"
+)
 
 // Calculates the periodic autocorrelation of a bit sequence.
 // Returns an array of correlation values for shifts 0 to L-1.
 // Values are normalized between -1 and 1.
+// Long sequences are correlated through the FFT, see PeriodicCorrelation.
 func CalculatePeriodicAutocorrelation(seq BitSequence) []float32 {
 	L := seq.Len()
 	if L == 0 {
 		return []float32{}
 	}
+	chips := signChips(&seq, L)
+	sums := PeriodicCorrelation(chips, chips)
 	autocorr := make([]float32, L)
-
-	for shift := 0; shift < L; shift++ {
-		sum := 0
-		for i := 0; i < L; i++ {
-			b1 := seq.Get(i)
-			b2 := seq.Get((i + shift) % L) // Apply shift with periodic boundary
-			if b1 == b2 {
-				sum++ // Agreement
-			} else {
-				sum-- // Disagreement
-			}
-		}
+	for shift, sum := range sums {
 		autocorr[shift] = float32(sum) / float32(L)
 	}
 	return autocorr
@@ -85,3 +80,73 @@ func CalculateCorrelationSum(signal1 []float32, signal2 []float32) float32 {
 	}
 	return sum
 }
+
+// CalculatePeriodicCrossCorrelation calculates the normalized cross-correlation of two periodic signals
+// over one period of the longer one, with signal2 delayed by shift samples. The shorter signal is repeated,
+// so the longer length must be a multiple of the shorter one. Returns a value between -1 and 1.
+func CalculatePeriodicCrossCorrelation(signal1 []float32, signal2 []float32, shift int) float32 {
+	L1, L2 := len(signal1), len(signal2)
+	if L1 == 0 || L2 == 0 || max(L1, L2)%min(L1, L2) != 0 {
+		panic("Signals must be non-empty and the longer length a multiple of the shorter for periodic cross-correlation.")
+	}
+	L := max(L1, L2)
+	sum := float32(0.0)
+	for i := 0; i < L; i++ {
+		j := ((i-shift)%L2 + L2) % L2
+		sum += signal1[i%L1] * signal2[j]
+	}
+	return sum / float32(L)
+}
+
+// Correlation functions of two codes as sums of ±1 chip products, normalize by dividing by Length.
+// A shorter code is repeated to the length of the longer one, which must be a multiple of it.
+type CorrelationFunctions struct {
+	Length    int
+	Periodic  []int // θ(τ) = sum a_i b_(i+τ mod L) for τ = 0..L-1, also called the even correlation
+	Aperiodic []int // C(τ) = sum a_i b_(i+τ) over the overlap for τ = -(L-1)..L-1, at index τ+L-1
+	Odd       []int // C(τ) - C(τ-L) for τ = 0..L-1, seen when the data bit changes within the window
+}
+
+// Compute the periodic, aperiodic and odd correlation functions of two codes.
+// For a == b these are the autocorrelation functions.
+func NewCorrelationFunctions(a, b *BitSequence) *CorrelationFunctions {
+	L1, L2 := a.Len(), b.Len()
+	if max(L1, L2)%min(L1, L2) != 0 {
+		panic("The longer code length must be a multiple of the shorter for correlation functions.")
+	}
+	L := max(L1, L2)
+	aperiodic := AperiodicCorrelation(signChips(a, L), signChips(b, L))
+
+	// The periodic and odd correlations are C(τ) ± C(τ-L)
+	odd := make([]int, L)
+	for tau := range L {
+		odd[tau] = aperiodic[tau+L-1]
+		if tau > 0 {
+			odd[tau] -= aperiodic[tau-1] // C(τ-L) at index τ-L+L-1
+		}
+	}
+	return &CorrelationFunctions{Length: L, Periodic: foldAperiodic(aperiodic), Aperiodic: aperiodic, Odd: odd}
+}
+
+// Return the values divided by the code length
+func (c *CorrelationFunctions) Normalize(values []int) []float64 {
+	normalized := make([]float64, len(values))
+	for i, v := range values {
+		normalized[i] = float64(v) / float64(c.Length)
+	}
+	return normalized
+}
+
+// Count how often every distinct correlation value occurs, sorted by value
+func CorrelationHistogram(values []int) []CorrelationCount {
+	counts := make(map[int]int)
+	for _, v := range values {
+		counts[v]++
+	}
+	histogram := make([]CorrelationCount, 0, len(counts))
+	for v, count := range counts {
+		histogram = append(histogram, CorrelationCount{Value: v, Count: count})
+	}
+	sort.Slice(histogram, func(i, j int) bool { return histogram[i].Value < histogram[j].Value })
+	return histogram
+}
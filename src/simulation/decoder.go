@@ -1,13 +1,267 @@
 package simulation
 
+import (
+	"fmt"
+	"math"
+)
+
+// Decodes data encoded with EncodeWithGold, the code repeats for data longer than one period
 func DecodeWithGold(dataSequence BitSequence, goldCode BitSequence) *BitSequence {
-	if dataSequence.length > goldCode.length {
-		panic("Data sequence length higher than gold code length.")
+	return dataSequence.Xor(goldCode.Repeat(dataSequence.length))
+}
+
+// Decoder types of the general pipeline
+const (
+	DecoderXOR      = "xor"      // Descrambling, one chip decides every bit
+	DecoderMajority = "majority" // Hard decision over all chips of a bit
+	DecoderSoft     = "soft"     // Correlation with the code, reports the correlation of every bit
+	DecoderPreamble = "preamble" // Code phase estimated from a known preamble, then soft despreading
+)
+
+// Result of decoding a received chip sequence
+type DecodeResult struct {
+	Bits *BitSequence
+	// Correlation of every bit with the code normalized to [-1, 1], positive for a 1,
+	// nil for decoders without soft output
+	Correlations []float64
+	// Code phase in chips the decoder synchronized to, -1 for decoders that assume phase 0
+	CodePhase int
+	// Bits the decoder could not decide because all their chips were erased, 0 in Bits.
+	// Nil when the chips had no erasures.
+	Erased *BitSequence
+}
+
+// Decoder of chips produced by SpreadWithCode (or EncodeWithGold for a spreading factor of 1)
+type Decoder interface {
+	Decode(chips *BitSequence, code *BitSequence, spreadingFactor int) DecodeResult
+}
+
+// Decoder that ignores erased chips instead of reading them as zeros
+type ErasureDecoder interface {
+	Decoder
+	DecodeErasures(chips *ErasureSequence, code *BitSequence, spreadingFactor int) DecodeResult
+}
+
+// Decoder that synchronizes on a known preamble, which the transmitter sends before the data
+type PreambleDecoder interface {
+	Decoder
+	Preamble(codeLength, spreadingFactor int) *BitSequence
+}
+
+var (
+	decoders     = map[string]Decoder{}
+	decoderTypes []string
+)
+
+func init() {
+	RegisterDecoder(DecoderXOR, XORDecoder{})
+	RegisterDecoder(DecoderMajority, MajorityDecoder{})
+	RegisterDecoder(DecoderSoft, SoftDecoder{})
+	RegisterDecoder(DecoderPreamble, PhaseSyncDecoder{Inner: SoftDecoder{}})
+}
+
+// Register a decoder under the given type, panics if the type is taken
+func RegisterDecoder(decoderType string, decoder Decoder) {
+	if _, ok := decoders[decoderType]; ok {
+		panic(fmt.Sprintf("Decoder %q is already registered", decoderType))
+	}
+	decoders[decoderType] = decoder
+	decoderTypes = append(decoderTypes, decoderType)
+}
+
+// Return the decoder of the given type, false if none is registered
+func LookupDecoder(decoderType string) (Decoder, bool) {
+	decoder, ok := decoders[decoderType]
+	return decoder, ok
+}
+
+// Return the registered decoder types in registration order
+func DecoderTypes() []string {
+	return append([]string(nil), decoderTypes...)
+}
+
+// Decodes received chips with the decoder. Chips with erasures go to DecodeErasures of an
+// ErasureDecoder, other decoders read erased chips as zeros.
+func DecodeReceived(decoder Decoder, chips *ErasureSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	if erasureDecoder, ok := decoder.(ErasureDecoder); ok && chips.ErasureCount() > 0 {
+		return erasureDecoder.DecodeErasures(chips, code, spreadingFactor)
 	}
-	decodedSequence := NewBitSequence(dataSequence.length)
-	for i := range dataSequence.length {
-		nextBit := dataSequence.Get(i) ^ goldCode.Get(i)
-		decodedSequence.Set(i, nextBit)
+	return decoder.Decode(chips.Bits, code, spreadingFactor)
+}
+
+// Return the default decoder of a spreading mode: descrambling for scrambled data
+// and majority despreading for DSSS
+func DefaultDecoderType(spreadingMode string) string {
+	if spreadingMode == SpreadingModeDSSS {
+		return DecoderMajority
+	}
+	return DecoderXOR
+}
+
+// Descrambles the chips and takes the first chip of every bit, without any processing gain.
+// For a spreading factor of 1 it is the inverse of EncodeWithGold.
+type XORDecoder struct{}
+
+func (XORDecoder) Decode(chips *BitSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	checkChipCount(chips, spreadingFactor)
+	descrambled := chips.Xor(code.Repeat(chips.length))
+	decoded := NewBitSequence(chips.length / spreadingFactor)
+	for i := range decoded.length {
+		decoded.Set(i, descrambled.Get(i*spreadingFactor))
+	}
+	return DecodeResult{Bits: decoded, CodePhase: -1}
+}
+
+// A bit is erased when the chip deciding it is
+func (d XORDecoder) DecodeErasures(chips *ErasureSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	result := d.Decode(chips.Bits, code, spreadingFactor)
+	result.Erased = NewBitSequence(result.Bits.length)
+	for i := range result.Bits.length {
+		if chips.IsErased(i * spreadingFactor) {
+			result.Bits.Set(i, 0)
+			result.Erased.Set(i, 1)
+		}
+	}
+	return result
+}
+
+// Hard-decision majority despreading, see DespreadWithCode
+type MajorityDecoder struct{}
+
+func (MajorityDecoder) Decode(chips *BitSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	return DecodeResult{Bits: DespreadWithCode(chips, code, spreadingFactor), CodePhase: -1}
+}
+
+// The majority is taken over the chips that were not erased, a bit is erased only with all its chips
+func (MajorityDecoder) DecodeErasures(chips *ErasureSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	result := despreadErasures(chips, code, spreadingFactor)
+	result.Correlations = nil
+	return result
+}
+
+// Correlation despreading: every bit is decided by the sign of the correlation of its ±1 chips
+// with the code. On the binary channel it decides the same bits as MajorityDecoder, but also
+// reports how far every decision is from the threshold.
+type SoftDecoder struct{}
+
+func (SoftDecoder) Decode(chips *BitSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	checkChipCount(chips, spreadingFactor)
+	disagreements := chips.Xor(code.Repeat(chips.length))
+	decoded := NewBitSequence(chips.length / spreadingFactor)
+	correlations := make([]float64, decoded.length)
+	for i := range decoded.length {
+		ones := disagreements.onesInRange(i*spreadingFactor, (i+1)*spreadingFactor)
+		correlations[i] = float64(2*ones-spreadingFactor) / float64(spreadingFactor)
+		if correlations[i] > 0 {
+			decoded.Set(i, 1)
+		}
+	}
+	return DecodeResult{Bits: decoded, Correlations: correlations, CodePhase: -1}
+}
+
+// Erased chips add nothing to the correlation, which is still normalized by the spreading factor,
+// so erasures weaken the decision margin. A bit is erased only with all its chips.
+func (SoftDecoder) DecodeErasures(chips *ErasureSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	return despreadErasures(chips, code, spreadingFactor)
+}
+
+// Correlation despreading over the chips that were not erased
+func despreadErasures(chips *ErasureSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	checkChipCount(chips.Bits, spreadingFactor)
+	known := chips.Erased.Not()
+	disagreements := chips.Bits.Xor(code.Repeat(chips.Len())).And(known)
+	decoded := NewBitSequence(chips.Len() / spreadingFactor)
+	erased := NewBitSequence(decoded.length)
+	correlations := make([]float64, decoded.length)
+	for i := range decoded.length {
+		knownChips := known.onesInRange(i*spreadingFactor, (i+1)*spreadingFactor)
+		if knownChips == 0 {
+			erased.Set(i, 1)
+			continue
+		}
+		ones := disagreements.onesInRange(i*spreadingFactor, (i+1)*spreadingFactor)
+		correlations[i] = float64(2*ones-knownChips) / float64(spreadingFactor)
+		if correlations[i] > 0 {
+			decoded.Set(i, 1)
+		}
+	}
+	return DecodeResult{Bits: decoded, Correlations: correlations, CodePhase: -1, Erased: erased}
+}
+
+// Estimates the code phase of the transmitter from a known preamble and decodes the data after it
+// with Inner and the code rotated to that phase. The preamble is the Barker code of 13 bits repeated
+// to span at least one code period, so every phase can be told apart.
+type PhaseSyncDecoder struct {
+	Inner Decoder
+}
+
+// Return the preamble bits sent before the data
+func (PhaseSyncDecoder) Preamble(codeLength, spreadingFactor int) *BitSequence {
+	length := max(13, (codeLength+spreadingFactor-1)/spreadingFactor)
+	return NewBarkerCode(13).Chips().Repeat(length)
+}
+
+func (d PhaseSyncDecoder) Decode(chips *BitSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	return d.DecodeErasures(NewErasureSequence(chips), code, spreadingFactor)
+}
+
+// Erased preamble chips are left out of the phase estimate, the data chips go to Inner
+// through DecodeReceived
+func (d PhaseSyncDecoder) DecodeErasures(chips *ErasureSequence, code *BitSequence, spreadingFactor int) DecodeResult {
+	checkChipCount(chips.Bits, spreadingFactor)
+	preamble := d.Preamble(code.length, spreadingFactor)
+	preambleChips := preamble.length * spreadingFactor
+	if chips.Len() <= preambleChips {
+		panic("Chip sequence is not longer than the preamble")
+	}
+	phase := estimateCodePhase(chips.Slice(0, preambleChips), preamble, code, spreadingFactor)
+	result := DecodeReceived(d.Inner, chips.Slice(preambleChips, chips.Len()), code.Rotate(phase+preambleChips), spreadingFactor)
+	result.CodePhase = phase
+	return result
+}
+
+// Return the cyclic code phase φ in 0..L-1 at which the chips best match SpreadWithCode(known,
+// code.Rotate(φ), spreadingFactor). The known data is removed from the chips, which leaves the
+// code with errors, and the periodic correlation of the chips folded onto one code period with
+// the code peaks at φ.
+func EstimateCodePhase(chips *BitSequence, known *BitSequence, code *BitSequence, spreadingFactor int) int {
+	return estimateCodePhase(NewErasureSequence(chips), known, code, spreadingFactor)
+}
+
+// EstimateCodePhase leaving erased chips out of the folded correlation
+func estimateCodePhase(chips *ErasureSequence, known *BitSequence, code *BitSequence, spreadingFactor int) int {
+	// Spreading with a single zero chip just repeats every known bit
+	residual := chips.Bits.Xor(SpreadWithCode(known, NewBitSequence(1), spreadingFactor))
+	folded := make([]int, code.length)
+	for k := range residual.length {
+		if !chips.IsErased(k) {
+			folded[k%code.length] += 2*int(residual.Get(k)) - 1
+		}
+	}
+	correlation := PeriodicCorrelation(folded, signChips(code, code.length))
+	best := 0
+	for tau, value := range correlation {
+		if value > correlation[best] {
+			best = tau
+		}
+	}
+	return best
+}
+
+// Return the mean absolute correlation of soft decisions, the average decision margin
+func MeanAbsCorrelation(correlations []float64) float64 {
+	if len(correlations) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, c := range correlations {
+		sum += math.Abs(c)
+	}
+	return sum / float64(len(correlations))
+}
+
+func checkChipCount(chips *BitSequence, spreadingFactor int) {
+	if spreadingFactor < 1 || chips.length%spreadingFactor != 0 {
+		panic("Chip count must be a multiple of the spreading factor")
 	}
-	return decodedSequence
 }
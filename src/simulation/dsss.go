@@ -0,0 +1,46 @@
+package simulation
+
+import "math"
+
+// Spreading modes of the general pipeline
+const (
+	SpreadingModeScramble = "scramble" // Every data bit is XORed with a single chip, the sequence keeps its length
+	SpreadingModeDSSS     = "dsss"     // Every data bit is expanded into spreadingFactor chips
+)
+
+// Spreads every data bit over spreadingFactor chips: chip k of the result is data bit k / spreadingFactor
+// XOR code chip k mod L. With spreadingFactor equal to the code length every bit carries one full code
+// period, shorter factors use consecutive segments of the code and longer ones repeat it.
+func SpreadWithCode(data *BitSequence, code *BitSequence, spreadingFactor int) *BitSequence {
+	if spreadingFactor < 1 {
+		panic("Spreading factor must be positive")
+	}
+	ones := NewBitSequence(spreadingFactor).Not()
+	expanded := NewBitSequence(data.length * spreadingFactor)
+	for i := range data.length {
+		if data.Get(i) == 1 {
+			expanded.orShifted(ones, i*spreadingFactor)
+		}
+	}
+	return expanded.Xor(code.Repeat(expanded.length))
+}
+
+// Despreads chips produced by SpreadWithCode with a hard majority decision, which for binary chips is
+// the sign of the correlation with the code: a bit is 1 when more than half of its chips disagree
+// with the code. Ties of an even spreading factor decide 0.
+func DespreadWithCode(chips *BitSequence, code *BitSequence, spreadingFactor int) *BitSequence {
+	checkChipCount(chips, spreadingFactor)
+	disagreements := chips.Xor(code.Repeat(chips.length))
+	decoded := NewBitSequence(chips.length / spreadingFactor)
+	for i := range decoded.length {
+		if 2*disagreements.onesInRange(i*spreadingFactor, (i+1)*spreadingFactor) > spreadingFactor {
+			decoded.Set(i, 1)
+		}
+	}
+	return decoded
+}
+
+// Returns the processing gain 10 log10(SF) in dB of spreading every bit over spreadingFactor chips
+func ProcessingGain(spreadingFactor int) float64 {
+	return 10 * math.Log10(float64(spreadingFactor))
+}
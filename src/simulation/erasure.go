@@ -0,0 +1,93 @@
+package simulation
+
+import (
+	"log"
+	"math/rand"
+)
+
+// Bit sequence of a receiver that can mark positions as unknown instead of deciding them:
+// Erased has a 1 at every erased position, where Bits holds 0
+type ErasureSequence struct {
+	Bits   *BitSequence
+	Erased *BitSequence
+}
+
+// Wrap a sequence without erasures
+func NewErasureSequence(bits *BitSequence) *ErasureSequence {
+	return &ErasureSequence{Bits: bits, Erased: NewBitSequence(bits.length)}
+}
+
+// Return the total number of positions in the sequence
+func (e *ErasureSequence) Len() int {
+	return e.Bits.length
+}
+
+// Return true if the position is erased
+func (e *ErasureSequence) IsErased(pos int) bool {
+	return e.Erased.Get(pos) == 1
+}
+
+// Return the number of erased positions
+func (e *ErasureSequence) ErasureCount() int {
+	return e.Erased.OnesCount()
+}
+
+// Return the positions start..end-1 as a new sequence, the range must not be empty
+func (e *ErasureSequence) Slice(start, end int) *ErasureSequence {
+	return &ErasureSequence{Bits: e.Bits.Slice(start, end), Erased: e.Erased.Slice(start, end)}
+}
+
+// Return the bits with every erased position replaced by a fair coin flip, the hard decision
+// of a receiver that cannot mark erasures
+func (e *ErasureSequence) Resolve(rng *rand.Rand) *BitSequence {
+	resolved := e.Bits.Clone()
+	for i := range e.Len() {
+		if e.IsErased(i) && rng.Intn(2) == 1 {
+			resolved.Set(i, 1)
+		}
+	}
+	return resolved
+}
+
+// Convert the sequence to a string of '0', '1' and '?' for erased positions
+func (e *ErasureSequence) String() string {
+	return MarkErasures(e.Bits, e.Erased)
+}
+
+// Return the bits as a string with '?' at every erased position, erased may be nil when there are none
+func MarkErasures(bits *BitSequence, erased *BitSequence) string {
+	str := []byte(bits.String())
+	if erased != nil {
+		for i := range str {
+			if erased.Get(i) == 1 {
+				str[i] = '?'
+			}
+		}
+	}
+	return string(str)
+}
+
+// Passes the sequence through the channel of the error model and returns the received sequence with
+// the number of flipped bits. Only the erasure channel erases positions, and it never flips a bit.
+func ApplyChannel(sequence *BitSequence, model ErrorModel, rng *rand.Rand) (*ErasureSequence, int) {
+	if model.Type != ErrorModelErasure {
+		corrupted, errorsIntroduced := ApplyErrorModel(sequence, model, rng)
+		return NewErasureSequence(corrupted), errorsIntroduced
+	}
+	if model.Rate <= 0 || model.Rate > 1 {
+		log.Printf("Erasure rate not within (0, 1]")
+		return NewErasureSequence(sequence.Clone()), 0
+	}
+	return eraseBits(sequence, model.Rate, rng), 0
+}
+
+// Erases every bit independently with probability rate, a binary erasure channel
+func eraseBits(sequence *BitSequence, rate float64, rng *rand.Rand) *ErasureSequence {
+	erased := NewBitSequence(sequence.length)
+	for i := range sequence.length {
+		if rng.Float64() < rate {
+			erased.Set(i, 1)
+		}
+	}
+	return &ErasureSequence{Bits: sequence.And(erased.Not()), Erased: erased}
+}
@@ -1,52 +1,192 @@
 package simulation
 
 import (
+	"fmt"
 	"log"
+	"math"
 	"math/rand"
 )
 
-// Introduces errors to a bit sequence based on specified parameters
-func AddErrors(sequence *BitSequence, errorRate float64, errorType string) (*BitSequence, int) {
-	if errorRate <= 0 || errorRate > 1 {
-		// Return copy of original sequence with no errors
-		log.Printf("Error rate not within (0, 1)")
-		result := NewBitSequence(sequence.Len())
-		for i := range sequence.Len() {
-			result.Set(i, sequence.Get(i))
-		}
-		return result, 0
-	}
+// Channel error models of the error injection module
+const (
+	ErrorModelRandom         = "random"          // Independent errors of probability Rate, a binary symmetric channel
+	ErrorModelBurst          = "burst"           // Non-overlapping bursts covering a fraction Rate of the bits on average
+	ErrorModelExact          = "exact"           // Exactly round(Rate * N) errors at distinct random positions
+	ErrorModelGilbertElliott = "gilbert-elliott" // Two-state Markov channel with a good and a bad state
+	ErrorModelErasure        = "erasure"         // Every bit is erased with probability Rate, a binary erasure channel
+)
 
-	corrupted := NewBitSequence(sequence.Len())
-	errorsIntroduced := 0
+// Burst length distributions of the burst model
+const (
+	BurstFixed     = "fixed"     // Every burst is BurstLength bits long
+	BurstGeometric = "geometric" // Burst lengths are geometric on 1, 2, ... with mean BurstLength
+)
 
-	// Copy original sequence
-	for i := range sequence.Len() {
-		corrupted.Set(i, sequence.Get(i))
+// Burst length of AddErrors
+const DefaultBurstLength = 3
+
+// Parameters of a channel error model, probabilities are fractions
+type ErrorModel struct {
+	Type              string
+	Rate              float64 // Error probability of random and burst errors, error fraction of exact counts, erasure probability
+	BurstLength       int     // Fixed or mean burst length
+	BurstDistribution string  // BurstFixed or BurstGeometric
+	// Gilbert-Elliott channel: transition probabilities per bit and the error probability in each state
+	GoodToBad     float64
+	BadToGood     float64
+	GoodErrorRate float64
+	BadErrorRate  float64
+}
+
+// Introduces errors to a bit sequence based on specified parameters, drawing from rng.
+// Burst errors use fixed bursts of DefaultBurstLength bits. Erased bits are guessed, use
+// ApplyChannel to keep the erasures.
+func AddErrors(sequence *BitSequence, errorRate float64, errorType string, rng *rand.Rand) (*BitSequence, int) {
+	return ApplyErrorModel(sequence, ErrorModel{Type: errorType, Rate: errorRate, BurstLength: DefaultBurstLength, BurstDistribution: BurstFixed}, rng)
+}
+
+// Introduces errors drawn from the error model to a copy of the sequence and returns it
+// with the number of flipped bits. Bits of the erasure channel are replaced by a fair coin flip
+// where erased, see ErasureSequence.Resolve.
+func ApplyErrorModel(sequence *BitSequence, model ErrorModel, rng *rand.Rand) (*BitSequence, int) {
+	if model.Type == ErrorModelGilbertElliott {
+		return gilbertElliottErrors(sequence, model, rng)
+	}
+	if model.Type == ErrorModelExact && model.Rate == 0 {
+		return sequence.Clone(), 0
+	}
+	if model.Rate <= 0 || model.Rate > 1 {
+		// Return copy of original sequence with no errors
+		log.Printf("Error rate not within (0, 1)")
+		return sequence.Clone(), 0
 	}
 
-	if errorType == "random" {
-		// Random errors
+	switch model.Type {
+	case ErrorModelRandom:
+		corrupted := sequence.Clone()
+		errorsIntroduced := 0
 		for i := range sequence.Len() {
-			if rand.Float64() < errorRate {
+			if rng.Float64() < model.Rate {
 				// Flip the bit
 				corrupted.Set(i, 1-corrupted.Get(i))
 				errorsIntroduced++
 			}
 		}
-	} else if errorType == "burst" {
-		// Burst errors - simplified implementation
-		burstLength := 3
-		numBursts := int(float64(sequence.Len()) * errorRate / float64(burstLength))
-		
-		for range numBursts {
-			startPos := rand.Intn(sequence.Len() - burstLength)
-			for i := 0; i < burstLength && startPos+i < sequence.Len(); i++ {
-				corrupted.Set(startPos+i, 1-corrupted.Get(startPos+i))
-				errorsIntroduced++
-			}
+		return corrupted, errorsIntroduced
+	case ErrorModelBurst:
+		return burstErrors(sequence, model, rng)
+	case ErrorModelExact:
+		return exactErrors(sequence, int(math.Round(model.Rate*float64(sequence.Len()))), rng)
+	case ErrorModelErasure:
+		guessed := eraseBits(sequence, model.Rate, rng).Resolve(rng)
+		return guessed, guessed.HammingDistance(sequence)
+	}
+	return sequence.Clone(), 0
+}
+
+// Flips every bit of non-overlapping bursts. The gaps between bursts are geometric with mean
+// B(1-r)/r for a mean burst length B, so a fraction r of the bits is in a burst on average.
+func burstErrors(sequence *BitSequence, model ErrorModel, rng *rand.Rand) (*BitSequence, int) {
+	meanLength := float64(max(model.BurstLength, 1))
+	gapEnd := 1 / (1 + meanLength*(1-model.Rate)/model.Rate)
+	errors := NewBitSequence(sequence.Len())
+	errorsIntroduced := 0
+	for pos := geometricFailures(gapEnd, rng); pos < sequence.Len(); pos += geometricFailures(gapEnd, rng) {
+		length := max(model.BurstLength, 1)
+		if model.BurstDistribution == BurstGeometric {
+			length = 1 + geometricFailures(1/meanLength, rng)
 		}
+		for i := pos; i < min(pos+length, sequence.Len()); i++ {
+			errors.Set(i, 1)
+			errorsIntroduced++
+		}
+		pos += length
+	}
+	return sequence.Xor(errors), errorsIntroduced
+}
+
+// Flips count distinct bits chosen uniformly, with Floyd's sampling algorithm
+func exactErrors(sequence *BitSequence, count int, rng *rand.Rand) (*BitSequence, int) {
+	n := sequence.Len()
+	count = min(count, n)
+	errors := NewBitSequence(n)
+	for j := n - count; j < n; j++ {
+		pos := rng.Intn(j + 1)
+		if errors.Get(pos) == 1 {
+			pos = j
+		}
+		errors.Set(pos, 1)
 	}
+	return sequence.Xor(errors), count
+}
 
+// Runs the two-state Markov chain over the sequence, starting in its stationary distribution.
+// Every bit is flipped with the error probability of the current state, then the state changes.
+func gilbertElliottErrors(sequence *BitSequence, model ErrorModel, rng *rand.Rand) (*BitSequence, int) {
+	corrupted := sequence.Clone()
+	errorsIntroduced := 0
+	bad := rng.Float64() < model.BadStateProbability()
+	for i := range sequence.Len() {
+		errorRate := model.GoodErrorRate
+		if bad {
+			errorRate = model.BadErrorRate
+		}
+		if rng.Float64() < errorRate {
+			corrupted.Set(i, 1-corrupted.Get(i))
+			errorsIntroduced++
+		}
+		if bad {
+			bad = rng.Float64() >= model.BadToGood
+		} else {
+			bad = rng.Float64() < model.GoodToBad
+		}
+	}
 	return corrupted, errorsIntroduced
 }
+
+// Return the number of failures before the first success of independent trials with success
+// probability p, a geometric variable on 0, 1, ... with mean (1-p)/p
+func geometricFailures(p float64, rng *rand.Rand) int {
+	if p >= 1 {
+		return 0
+	}
+	return int(math.Log(1-rng.Float64()) / math.Log(1-p))
+}
+
+// Return the stationary probability of the bad state of the Gilbert-Elliott channel,
+// 0 when the chain never changes state
+func (m ErrorModel) BadStateProbability() float64 {
+	if m.GoodToBad+m.BadToGood == 0 {
+		return 0
+	}
+	return m.GoodToBad / (m.GoodToBad + m.BadToGood)
+}
+
+// Return the long-run fraction of flipped bits of the model, for the erasure channel the fraction
+// of erased bits
+func (m ErrorModel) ExpectedRate() float64 {
+	switch m.Type {
+	case ErrorModelGilbertElliott:
+		bad := m.BadStateProbability()
+		return (1-bad)*m.GoodErrorRate + bad*m.BadErrorRate
+	case ErrorModelRandom, ErrorModelBurst, ErrorModelExact, ErrorModelErasure:
+		return m.Rate
+	}
+	return 0
+}
+
+// Describe the model and its parameters
+func (m ErrorModel) String() string {
+	switch m.Type {
+	case ErrorModelBurst:
+		return fmt.Sprintf("burst, rate %.2f%%, %s length %d", m.Rate*100, m.BurstDistribution, m.BurstLength)
+	case ErrorModelExact:
+		return fmt.Sprintf("exact count, %.2f%% of the bits", m.Rate*100)
+	case ErrorModelErasure:
+		return fmt.Sprintf("binary erasure channel, erasure probability %.2f%%", m.Rate*100)
+	case ErrorModelGilbertElliott:
+		return fmt.Sprintf("Gilbert-Elliott, P(G->B) = %.4f, P(B->G) = %.4f, error rate good %.4f / bad %.4f, expected rate %.2f%%",
+			m.GoodToBad, m.BadToGood, m.GoodErrorRate, m.BadErrorRate, m.ExpectedRate()*100)
+	}
+	return fmt.Sprintf("%s, rate %.2f%%", m.Type, m.Rate*100)
+}
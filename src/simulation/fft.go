@@ -0,0 +1,91 @@
+package simulation
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// Return the discrete Fourier transform X_k = sum x_j e^(-2πijk/n) of a sequence of any length.
+// Powers of two use the radix-2 FFT, other lengths Bluestein's chirp-z algorithm on top of it,
+// so the cost is O(n log n) either way.
+func DFT(x []complex128) []complex128 {
+	n := len(x)
+	out := make([]complex128, n)
+	if n == 0 {
+		return out
+	}
+	if n&(n-1) == 0 {
+		copy(out, x)
+		FFT(out, false)
+		return out
+	}
+
+	// nk = (k² + n² - (k-n)²) / 2 turns the transform into a convolution with the chirp e^(iπk²/n)
+	m := 1 << bits.Len(uint(2*n-2))
+	chirp := make([]complex128, n)
+	for k := range n {
+		// k² mod 2n keeps the angle small for long sequences
+		angle := math.Pi * float64(k*k%(2*n)) / float64(n)
+		chirp[k] = cmplx.Exp(complex(0, -angle))
+	}
+	a := make([]complex128, m)
+	b := make([]complex128, m)
+	for k := range n {
+		a[k] = x[k] * chirp[k]
+	}
+	b[0] = cmplx.Conj(chirp[0])
+	for k := 1; k < n; k++ {
+		b[k] = cmplx.Conj(chirp[k])
+		b[m-k] = b[k]
+	}
+	FFT(a, false)
+	FFT(b, false)
+	for i := range m {
+		a[i] *= b[i]
+	}
+	FFT(a, true)
+	for k := range n {
+		out[k] = a[k] * chirp[k]
+	}
+	return out
+}
+
+// In-place iterative radix-2 FFT, the length must be a power of two.
+// The inverse transform is scaled by 1/n, so FFT(FFT(x, false), true) returns x.
+func FFT(x []complex128, inverse bool) {
+	n := len(x)
+	if n&(n-1) != 0 {
+		panic("FFT length must be a power of two")
+	}
+	if n <= 1 {
+		return
+	}
+	shift := 64 - bits.Len(uint(n-1))
+	for i := range n {
+		if j := int(bits.Reverse64(uint64(i)) >> shift); i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	sign := -1.0
+	if inverse {
+		sign = 1.0
+	}
+	for size := 2; size <= n; size *= 2 {
+		step := cmplx.Exp(complex(0, sign*2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := range size / 2 {
+				even, odd := x[start+k], x[start+k+size/2]*w
+				x[start+k] = even + odd
+				x[start+k+size/2] = even - odd
+				w *= step
+			}
+		}
+	}
+	if inverse {
+		for i := range n {
+			x[i] /= complex(float64(n), 0)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package simulation
+
+import (
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// Codes at least this long are correlated through the FFT in O(L log L) instead of directly in O(L^2).
+// Below it the direct sums are about as fast as the three zero-padded transforms.
+const FFTCorrelationThreshold = 256
+
+// Return the ±1 chips of a sequence repeated to the given length
+func signChips(seq *BitSequence, length int) []int {
+	chips := make([]int, length)
+	for i := range length {
+		chips[i] = 2*int(seq.Get(i%seq.length)) - 1
+	}
+	return chips
+}
+
+// Return the periodic correlation θ(τ) = sum a_i b_(i+τ mod L) for τ = 0..L-1 of two equally long
+// integer sequences, through the FFT from FFTCorrelationThreshold on
+func PeriodicCorrelation(a, b []int) []int {
+	if len(a) != len(b) {
+		panic("Sequences must have the same length for correlation.")
+	}
+	if len(a) < FFTCorrelationThreshold {
+		return periodicCorrelationDirect(a, b)
+	}
+	return foldAperiodic(aperiodicCorrelationFFT(a, b))
+}
+
+// Return the aperiodic correlation C(τ) = sum a_i b_(i+τ) over the overlap for τ = -(L-1)..L-1,
+// at index τ+L-1, of two equally long integer sequences, through the FFT from FFTCorrelationThreshold on
+func AperiodicCorrelation(a, b []int) []int {
+	if len(a) != len(b) {
+		panic("Sequences must have the same length for correlation.")
+	}
+	if len(a) < FFTCorrelationThreshold {
+		return aperiodicCorrelationDirect(a, b)
+	}
+	return aperiodicCorrelationFFT(a, b)
+}
+
+func periodicCorrelationDirect(a, b []int) []int {
+	L := len(a)
+	periodic := make([]int, L)
+	for tau := range L {
+		sum := 0
+		for i := range L - tau {
+			sum += a[i] * b[i+tau]
+		}
+		for i := L - tau; i < L; i++ {
+			sum += a[i] * b[i+tau-L]
+		}
+		periodic[tau] = sum
+	}
+	return periodic
+}
+
+func aperiodicCorrelationDirect(a, b []int) []int {
+	L := len(a)
+	aperiodic := make([]int, max(2*L-1, 0))
+	for tau := -(L - 1); tau < L; tau++ {
+		sum := 0
+		for i := max(0, -tau); i < min(L, L-tau); i++ {
+			sum += a[i] * b[i+tau]
+		}
+		aperiodic[tau+L-1] = sum
+	}
+	return aperiodic
+}
+
+// Zero-pad both sequences to a power of two of at least 2L-1 samples, so the circular correlation
+// IFFT(conj(FFT(a)) FFT(b)) does not wrap around, and round the result back to integers
+func aperiodicCorrelationFFT(a, b []int) []int {
+	L := len(a)
+	m := 1 << bits.Len(uint(2*L-2))
+	fa := make([]complex128, m)
+	fb := make([]complex128, m)
+	for i := range L {
+		fa[i] = complex(float64(a[i]), 0)
+		fb[i] = complex(float64(b[i]), 0)
+	}
+	FFT(fa, false)
+	FFT(fb, false)
+	for i := range m {
+		fa[i] = cmplx.Conj(fa[i]) * fb[i]
+	}
+	FFT(fa, true)
+
+	aperiodic := make([]int, 2*L-1)
+	for tau := -(L - 1); tau < L; tau++ {
+		aperiodic[tau+L-1] = int(math.Round(real(fa[(tau+m)%m])))
+	}
+	return aperiodic
+}
+
+// Turn the aperiodic correlation into the periodic one, θ(τ) = C(τ) + C(τ-L)
+func foldAperiodic(aperiodic []int) []int {
+	L := (len(aperiodic) + 1) / 2
+	periodic := make([]int, L)
+	for tau := range L {
+		periodic[tau] = aperiodic[tau+L-1]
+		if tau > 0 {
+			periodic[tau] += aperiodic[tau-1]
+		}
+	}
+	return periodic
+}
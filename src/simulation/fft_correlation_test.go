@@ -0,0 +1,113 @@
+package simulation
+
+import (
+	"fmt"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// Return length random ±1 chips
+func randomChips(length int, rng *rand.Rand) []int {
+	chips := make([]int, length)
+	for i := range chips {
+		chips[i] = 2*rng.Intn(2) - 1
+	}
+	return chips
+}
+
+// Lengths around the FFT threshold, non-powers of two and powers of two
+var correlationTestLengths = []int{
+	1, 2, 3, 7, 31, 100,
+	FFTCorrelationThreshold - 1, FFTCorrelationThreshold, FFTCorrelationThreshold + 1,
+	511, 512, 1000, 1023,
+}
+
+func TestCorrelationFFTMatchesDirect(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, length := range correlationTestLengths {
+		t.Run(fmt.Sprintf("L=%d", length), func(t *testing.T) {
+			a := randomChips(length, rng)
+			b := randomChips(length, rng)
+			wantAperiodic := aperiodicCorrelationDirect(a, b)
+			if got := aperiodicCorrelationFFT(a, b); !slices.Equal(got, wantAperiodic) {
+				t.Fatalf("aperiodic FFT correlation differs from the direct sums")
+			}
+			wantPeriodic := periodicCorrelationDirect(a, b)
+			if got := foldAperiodic(aperiodicCorrelationFFT(a, b)); !slices.Equal(got, wantPeriodic) {
+				t.Fatalf("periodic FFT correlation differs from the direct sums")
+			}
+			// The exported functions pick either method by length
+			if got := AperiodicCorrelation(a, b); !slices.Equal(got, wantAperiodic) {
+				t.Fatalf("AperiodicCorrelation differs from the direct sums")
+			}
+			if got := PeriodicCorrelation(a, b); !slices.Equal(got, wantPeriodic) {
+				t.Fatalf("PeriodicCorrelation differs from the direct sums")
+			}
+		})
+	}
+}
+
+func TestCorrelationFFTIntegerInputs(t *testing.T) {
+	// Folded chip counts as used by EstimateCodePhase are not limited to ±1
+	rng := rand.New(rand.NewSource(2))
+	for _, length := range []int{FFTCorrelationThreshold - 1, FFTCorrelationThreshold, 1023} {
+		a := make([]int, length)
+		for i := range a {
+			a[i] = rng.Intn(201) - 100
+		}
+		b := randomChips(length, rng)
+		if !slices.Equal(PeriodicCorrelation(a, b), periodicCorrelationDirect(a, b)) {
+			t.Fatalf("L=%d: periodic correlation of integer sequences differs from the direct sums", length)
+		}
+	}
+}
+
+func TestCorrelationMismatchedLengths(t *testing.T) {
+	for _, lengths := range [][2]int{{3, 4}, {FFTCorrelationThreshold, FFTCorrelationThreshold + 1}, {1000, 999}} {
+		a := make([]int, lengths[0])
+		b := make([]int, lengths[1])
+		for name, correlate := range map[string]func(a, b []int) []int{
+			"periodic":  PeriodicCorrelation,
+			"aperiodic": AperiodicCorrelation,
+		} {
+			t.Run(fmt.Sprintf("%s/%dx%d", name, lengths[0], lengths[1]), func(t *testing.T) {
+				defer func() {
+					if recover() == nil {
+						t.Fatalf("no panic on sequences of different length")
+					}
+				}()
+				correlate(a, b)
+			})
+		}
+	}
+}
+
+func benchmarkCorrelation(b *testing.B, correlate func(a, b []int) []int) {
+	for _, length := range []int{FFTCorrelationThreshold / 2, FFTCorrelationThreshold, 1023, 4095} {
+		rng := rand.New(rand.NewSource(1))
+		x := randomChips(length, rng)
+		y := randomChips(length, rng)
+		b.Run(fmt.Sprintf("L=%d", length), func(b *testing.B) {
+			for b.Loop() {
+				correlate(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkPeriodicCorrelationDirect(b *testing.B) {
+	benchmarkCorrelation(b, periodicCorrelationDirect)
+}
+
+func BenchmarkPeriodicCorrelationFFT(b *testing.B) {
+	benchmarkCorrelation(b, func(x, y []int) []int { return foldAperiodic(aperiodicCorrelationFFT(x, y)) })
+}
+
+func BenchmarkAperiodicCorrelationDirect(b *testing.B) {
+	benchmarkCorrelation(b, aperiodicCorrelationDirect)
+}
+
+func BenchmarkAperiodicCorrelationFFT(b *testing.B) {
+	benchmarkCorrelation(b, aperiodicCorrelationFFT)
+}
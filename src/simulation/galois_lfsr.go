@@ -0,0 +1,108 @@
+package simulation
+
+// Galois-configuration N-bit LFSR. The state is an element of GF(2)[x]/p(x) that is multiplied
+// by x every step: the register shifts left and, when the bit leaving it is 1, XORs the whole
+// feedback mask at once instead of scanning the taps like the Fibonacci LFSR.
+type GaloisLFSR struct {
+	state uint64
+	mask  uint64 // Feedback polynomial without the x^n term
+	n     uint   // Register width in bits (≤ 63)
+}
+
+// Initialize a new N-bit Galois LFSR with the given feedback mask
+func NewGaloisLFSR(seed uint64, mask uint64, n uint) *GaloisLFSR {
+	if n == 0 || n > 63 {
+		panic("Galois LFSR size must be between 1 and 63 bits")
+	}
+	if seed == 0 || seed >= (1<<n) {
+		panic("Seed must be non-zero and fit in N bits")
+	}
+	if mask >= (1 << n) {
+		panic("Feedback mask must fit in N bits")
+	}
+	return &GaloisLFSR{state: seed, mask: mask, n: n}
+}
+
+// Return the Galois feedback mask realizing the same feedback polynomial as the Fibonacci taps
+func FibonacciToGaloisMask(n uint, taps []uint) uint64 {
+	for _, t := range taps {
+		if t >= n {
+			panic("Tap position exceeds LFSR width")
+		}
+	}
+	return uint64(TapsToPolynomial(n, taps)) &^ (1 << n)
+}
+
+// Return the Fibonacci taps realizing the same feedback polynomial as the Galois mask
+func GaloisMaskToTaps(n uint, mask uint64) []uint {
+	return PolynomialToTaps(Polynomial(mask | 1<<n))
+}
+
+// Advance the LFSR by one bit and return the output bit
+func (g *GaloisLFSR) Shift() uint8 {
+	out := g.state >> (g.n - 1) & 1
+	g.state = g.state << 1 & (1<<g.n - 1)
+	if out == 1 {
+		g.state ^= g.mask
+	}
+	return uint8(out)
+}
+
+// Return the current state
+func (g *GaloisLFSR) State() uint64 {
+	return g.state
+}
+
+// Advance the LFSR by k steps at once: the state is multiplied by x^k mod p(x),
+// computed by square-and-multiply in O(n log k) word operations
+func (g *GaloisLFSR) Jump(k uint64) {
+	p := Polynomial(g.mask | 1<<g.n)
+	g.state = uint64(polyMulMod(Polynomial(g.state), polyPowMod(2, k, p), p))
+}
+
+// Return a Galois LFSR whose output sequence is identical to the output of this Fibonacci LFSR
+// from its current state on. Both registers realize the same feedback polynomial, so matching
+// the next n outputs is enough.
+func (l *LFSR) Galois() *GaloisLFSR {
+	next := *l
+	outputs := make([]uint8, l.n)
+	for i := range outputs {
+		outputs[i] = next.Shift()
+	}
+	return galoisFromOutputs(l.n, FibonacciToGaloisMask(l.n, l.taps), outputs)
+}
+
+// Find the Galois state whose first n outputs are the given bits. Output k depends only on
+// state bits n-1 .. n-1-k and flips with bit n-1-k, so the bits are fixed one at a time.
+func galoisFromOutputs(n uint, mask uint64, outputs []uint8) *GaloisLFSR {
+	var state uint64
+	for k, want := range outputs {
+		probe := GaloisLFSR{state: state, mask: mask, n: n}
+		var out uint8
+		for range k + 1 {
+			out = probe.Shift()
+		}
+		if out != want {
+			state |= 1 << (n - 1 - uint(k))
+		}
+	}
+	return &GaloisLFSR{state: state, mask: mask, n: n}
+}
+
+// Advance the Fibonacci LFSR by k steps without shifting bit by bit: the equivalent Galois
+// register jumps to n steps before the target and the last n outputs rebuild the state
+func (l *LFSR) Jump(k uint64) {
+	if l.n > 63 || k <= uint64(l.n) {
+		for range k {
+			l.Shift()
+		}
+		return
+	}
+	galois := l.Galois()
+	galois.Jump(k - uint64(l.n))
+	state := uint64(0)
+	for range l.n {
+		state = state<<1 | uint64(galois.Shift())
+	}
+	l.state = state
+}
@@ -0,0 +1,111 @@
+package simulation
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Primitive tap sets of degrees 3..16: the catalog polynomial and the two smallest primitive ones
+func primitiveTapSets(t *testing.T) map[uint][][]uint {
+	t.Helper()
+	sets := map[uint][][]uint{}
+	for n := uint(3); n <= 16; n++ {
+		sets[n] = append(sets[n], PrimitiveTaps(n))
+		for _, p := range FindPrimitivePolynomials(n, 2) {
+			sets[n] = append(sets[n], PolynomialToTaps(p))
+		}
+	}
+	return sets
+}
+
+// Return length output bits of a register
+func outputBits(shift func() uint8, length int) []uint8 {
+	bits := make([]uint8, length)
+	for i := range bits {
+		bits[i] = shift()
+	}
+	return bits
+}
+
+// Return the first position below limit at which window occurs in bits, -1 if none
+func findWindow(bits, window []uint8, limit int) int {
+	for pos := range limit {
+		if slices.Equal(bits[pos:pos+len(window)], window) {
+			return pos
+		}
+	}
+	return -1
+}
+
+func TestGaloisMatchesFibonacciMSequence(t *testing.T) {
+	for n, tapSets := range primitiveTapSets(t) {
+		for _, taps := range tapSets {
+			t.Run(fmt.Sprintf("n=%d/taps=%v", n, taps), func(t *testing.T) {
+				period := 1<<n - 1
+				fibonacci := NewLFSR(1, taps, n)
+				galois := fibonacci.Galois()
+				fibonacciBits := outputBits(fibonacci.Shift, 2*period)
+				galoisBits := outputBits(galois.Shift, 2*period)
+				if !slices.Equal(fibonacciBits, galoisBits) {
+					t.Fatalf("Galois output differs from the Fibonacci output")
+				}
+				if fibonacci.state != 1 {
+					t.Fatalf("Fibonacci state %d after two periods, want the seed", fibonacci.state)
+				}
+
+				// The register with the converted mask and any seed runs through the same m-sequence
+				// at some phase, which is found from its first n bits
+				ones := 0
+				for _, bit := range fibonacciBits[:period] {
+					ones += int(bit)
+				}
+				if ones != 1<<(n-1) {
+					t.Fatalf("m-sequence has %d ones, want %d", ones, 1<<(n-1))
+				}
+				independent := NewGaloisLFSR(1, FibonacciToGaloisMask(n, taps), n)
+				independentBits := outputBits(independent.Shift, period)
+				phase := findWindow(fibonacciBits, independentBits[:n], period)
+				if phase < 0 {
+					t.Fatalf("Galois output window not found in the m-sequence")
+				}
+				if !slices.Equal(fibonacciBits[phase:phase+period], independentBits) {
+					t.Fatalf("Galois output is not the m-sequence shifted by %d", phase)
+				}
+				if independent.State() != 1 {
+					t.Fatalf("Galois state %d after one period, want the seed", independent.State())
+				}
+			})
+		}
+	}
+}
+
+func TestJumpMatchesStepping(t *testing.T) {
+	for n, tapSets := range primitiveTapSets(t) {
+		taps := tapSets[0]
+		period := uint64(1)<<n - 1
+		for _, k := range []uint64{0, 1, uint64(n) - 1, uint64(n), uint64(n) + 1, 1000, period - 1, period, period + 1, 3*period + 17} {
+			t.Run(fmt.Sprintf("n=%d/k=%d", n, k), func(t *testing.T) {
+				jumped := NewLFSR(0b101, taps, n)
+				stepped := NewLFSR(0b101, taps, n)
+				jumped.Jump(k)
+				for range k {
+					stepped.Shift()
+				}
+				if jumped.state != stepped.state {
+					t.Fatalf("Fibonacci Jump(%d) state %d, stepping gives %d", k, jumped.state, stepped.state)
+				}
+
+				galoisJumped := NewGaloisLFSR(0b101, FibonacciToGaloisMask(n, taps), n)
+				galoisStepped := NewGaloisLFSR(0b101, FibonacciToGaloisMask(n, taps), n)
+				galoisJumped.Jump(k)
+				for range k {
+					galoisStepped.Shift()
+				}
+				if galoisJumped.State() != galoisStepped.State() {
+					t.Fatalf("Galois Jump(%d) state %d, stepping gives %d", k, galoisJumped.State(), galoisStepped.State())
+				}
+			})
+		}
+	}
+}
@@ -1,23 +1,15 @@
 package simulation
 
-// Encodes data with gold code
+// Encodes data with gold code, the code repeats for data longer than one period
 func EncodeWithGold(dataSequence BitSequence, goldCode BitSequence) *BitSequence {
-	// if dataSequence.length > goldCode.length {
-	// 	panic("Data sequence length higher than gold code length.")
-	// }
-	encodedSequence := NewBitSequence(dataSequence.length)
-	for i := range dataSequence.length {
-		nextBit := dataSequence.Get(i) ^ goldCode.Get(i%goldCode.length)
-		encodedSequence.Set(i, nextBit)
-	}
-	return encodedSequence
+	return dataSequence.Xor(goldCode.Repeat(dataSequence.length))
 }
 
-// Generates gold code of length 2^n - 1 with n bit wide lfsr's
+// Generates gold code of length 2^n - 1 with n bit wide lfsr's, run in their equivalent Galois form
 func GenerateGoldCode(n uint, poly1 []uint, seed1 uint64, poly2 []uint, seed2 uint64) *BitSequence {
 	codeLength := pow2(n) - 1
-	lfsr1 := NewLFSR(seed1, poly1, n)
-	lfsr2 := NewLFSR(seed2, poly2, n)
+	lfsr1 := NewLFSR(seed1, poly1, n).Galois()
+	lfsr2 := NewLFSR(seed2, poly2, n).Galois()
 	goldCode := NewBitSequence(codeLength)
 	for i := range codeLength {
 		bit1 := lfsr1.Shift()
@@ -0,0 +1,112 @@
+package simulation
+
+import "fmt"
+
+// Family of gold codes of a pair of LFSRs: the two m-sequences u and v plus u XOR v shifted
+// by every k = 0..N-1, i.e. N + 2 = 2^n + 1 codes of length N = 2^n - 1.
+// Codes are generated on demand, only u and v are kept.
+type GoldFamily struct {
+	N      uint
+	Taps1  []uint
+	Taps2  []uint
+	Length int
+
+	u *BitSequence
+	v *BitSequence
+}
+
+// Statistics of a single code of the family
+type GoldCodeStats struct {
+	Index                     int
+	Name                      string
+	Ones                      int
+	Zeros                     int
+	Balanced                  bool    // Ones and zeros differ by exactly one
+	MaxOffPeakAutocorrelation float32 // Normalized by the code length
+}
+
+// Create the gold family of two n-bit LFSRs with the given taps, both started from state 1
+func NewGoldFamily(n uint, taps1, taps2 []uint) *GoldFamily {
+	length := pow2(n) - 1
+	family := &GoldFamily{
+		N:      n,
+		Taps1:  taps1,
+		Taps2:  taps2,
+		Length: length,
+		u:      NewBitSequence(length),
+		v:      NewBitSequence(length),
+	}
+	lfsr1 := NewLFSR(1, taps1, n)
+	lfsr2 := NewLFSR(1, taps2, n)
+	for i := range length {
+		family.u.Set(i, lfsr1.Shift())
+		family.v.Set(i, lfsr2.Shift())
+	}
+	return family
+}
+
+// Return the number of codes in the family, 2^n + 1
+func (f *GoldFamily) Size() int {
+	return f.Length + 2
+}
+
+// Return the code with the given index: 0 is u, 1 is v and 2+k is u XOR (v shifted left by k)
+func (f *GoldFamily) Code(index int) *BitSequence {
+	if index < 0 || index >= f.Size() {
+		panic("Gold family index out of range")
+	}
+	switch index {
+	case 0:
+		return f.u.Clone()
+	case 1:
+		return f.v.Clone()
+	default:
+		return f.u.Xor(f.v.Rotate(index - 2))
+	}
+}
+
+// Return the display name of the code with the given index
+func GoldCodeName(index int) string {
+	switch index {
+	case 0:
+		return "u"
+	case 1:
+		return "v"
+	default:
+		return fmt.Sprintf("u ⊕ T^%d v", index-2)
+	}
+}
+
+// Return the display name of the code with the given index, see GoldCodeName
+func (f *GoldFamily) Name(index int) string {
+	return GoldCodeName(index)
+}
+
+// Compute the balance and the maximum off-peak periodic autocorrelation of a code
+func (f *GoldFamily) Stats(index int) GoldCodeStats {
+	code := f.Code(index)
+	ones := code.OnesCount()
+	zeros := code.length - ones
+
+	doubled := doubledWords(code)
+	maxOffPeak := 0
+	for shift := 1; shift < code.length; shift++ {
+		maxOffPeak = max(maxOffPeak, abs(cyclicCorrelation(code, doubled, shift)))
+	}
+
+	return GoldCodeStats{
+		Index:                     index,
+		Name:                      GoldCodeName(index),
+		Ones:                      ones,
+		Zeros:                     zeros,
+		Balanced:                  abs(ones-zeros) == 1,
+		MaxOffPeakAutocorrelation: float32(maxOffPeak) / float32(code.length),
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
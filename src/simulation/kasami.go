@@ -0,0 +1,111 @@
+package simulation
+
+import "fmt"
+
+// Kasami set of an n-bit LFSR (n even). With the m-sequence u of length N = 2^n - 1,
+// w = u decimated by 2^(n/2) + 1 has period 2^(n/2) - 1, and the small set holds u and
+// u XOR (w shifted by every j). The large set (n ≡ 2 mod 4) also uses v = u decimated by
+// 2^(n/2 + 1) + 1, which forms a preferred pair with u, and XORs every code of the gold
+// family of u and v with every shift of w.
+type KasamiSet struct {
+	N      uint
+	Taps   []uint
+	Large  bool
+	Length int
+
+	u *BitSequence
+	v *BitSequence // Large set only
+	w *BitSequence // Repeated to the full code length
+}
+
+// Create the small or large Kasami set of an n-bit LFSR with the given taps, started from state 1
+func NewKasamiSet(n uint, taps []uint, large bool) *KasamiSet {
+	family := CodeFamilyKasamiSmall
+	if large {
+		family = CodeFamilyKasamiLarge
+	}
+	if !CodeFamilySupported(family, n) {
+		panic(fmt.Sprintf("Kasami set is not defined for n = %d", n))
+	}
+	length := pow2(n) - 1
+	set := &KasamiSet{
+		N:      n,
+		Taps:   taps,
+		Large:  large,
+		Length: length,
+		u:      NewBitSequence(length),
+	}
+	lfsr := NewLFSR(1, taps, n)
+	for i := range length {
+		set.u.Set(i, lfsr.Shift())
+	}
+	set.w = Decimate(set.u, pow2(n/2)+1)
+	if large {
+		set.v = Decimate(set.u, pow2(n/2+1)+1)
+	}
+	return set
+}
+
+// Return the sequence taking every q-th bit of seq, seq[q*i mod L] for i = 0..L-1
+func Decimate(seq *BitSequence, q int) *BitSequence {
+	decimated := NewBitSequence(seq.length)
+	for i := range seq.length {
+		decimated.Set(i, seq.Get(q*i%seq.length))
+	}
+	return decimated
+}
+
+// Return the number of codes, 2^(n/2) for the small set and 2^(n/2) * (2^n + 1) for the large set
+func (k *KasamiSet) Size() int {
+	return k.shifts() * k.goldSize()
+}
+
+// Number of w shifts including none, 2^(n/2)
+func (k *KasamiSet) shifts() int {
+	return pow2(k.N / 2)
+}
+
+// Number of codes taken from the gold family of u and v, just u for the small set
+func (k *KasamiSet) goldSize() int {
+	if !k.Large {
+		return 1
+	}
+	return k.Length + 2
+}
+
+// Split an index into the gold family code (0 is u, 1 is v, 2+i is u XOR v shifted by i)
+// and the w shift (0 is none, 1+j is w shifted by j)
+func (k *KasamiSet) split(index int) (int, int) {
+	return index % k.goldSize(), index / k.goldSize()
+}
+
+// Return the code with the given index, see split for the order
+func (k *KasamiSet) Code(index int) *BitSequence {
+	if index < 0 || index >= k.Size() {
+		panic("Kasami set index out of range")
+	}
+	gold, wShift := k.split(index)
+	var code *BitSequence
+	switch gold {
+	case 0:
+		code = k.u.Clone()
+	case 1:
+		code = k.v.Clone()
+	default:
+		code = k.u.Xor(k.v.Rotate(gold - 2))
+	}
+	if wShift > 0 {
+		code = code.Xor(k.w.Rotate(wShift - 1))
+	}
+	return code
+}
+
+// Return the display name of the code with the given index
+func (k *KasamiSet) Name(index int) string {
+	gold, wShift := k.split(index)
+	name := GoldCodeName(gold)
+	if wShift > 0 {
+		name += fmt.Sprintf(" ⊕ T^%d w", wShift-1)
+	}
+	return name
+}
@@ -0,0 +1,58 @@
+package simulation
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Fail unless the periodic cross-correlation of every pair of codes and the off-peak
+// autocorrelation of every code only take allowed values
+func checkCorrelationValues(t *testing.T, set CodeSet, allowed map[int]bool) {
+	t.Helper()
+	codes := make([]*BitSequence, set.Size())
+	for i := range codes {
+		codes[i] = set.Code(i)
+	}
+	for i := range codes {
+		for j := i; j < len(codes); j++ {
+			for _, c := range CrossCorrelationSpectrum(codes[i], codes[j]) {
+				if i == j && c.Value == codes[i].Len() && c.Count == 1 {
+					continue // Autocorrelation peak
+				}
+				if !allowed[c.Value] {
+					t.Fatalf("codes %s and %s correlate to %d", set.Name(i), set.Name(j), c.Value)
+				}
+			}
+		}
+	}
+}
+
+func TestSmallKasamiSet(t *testing.T) {
+	for _, n := range []uint{6, 10} {
+		t.Run(fmt.Sprintf("n=%d", n), func(t *testing.T) {
+			set := NewKasamiSet(n, PrimitiveTaps(n), false)
+			if set.Size() != pow2(n/2) {
+				t.Fatalf("small set has %d codes, want 2^(n/2) = %d", set.Size(), pow2(n/2))
+			}
+			// Correlations of the small set take only -1, -s(n) and s(n) - 2 with s(n) = 2^(n/2) + 1
+			s := pow2(n/2) + 1
+			checkCorrelationValues(t, set, map[int]bool{-1: true, -s: true, s - 2: true})
+		})
+	}
+}
+
+func TestLargeKasamiSet(t *testing.T) {
+	for _, n := range []uint{6, 10} {
+		set := NewKasamiSet(n, PrimitiveTaps(n), true)
+		if want := pow2(n/2) * (pow2(n) + 1); set.Size() != want {
+			t.Fatalf("n=%d: large set has %d codes, want 2^(n/2)(2^n+1) = %d", n, set.Size(), want)
+		}
+	}
+
+	// Correlations of the large set take the values of the small set and of the preferred pair
+	n := uint(6)
+	s := pow2(n/2) + 1
+	tn := PreferredPairBound(n)
+	allowed := map[int]bool{-1: true, -s: true, s - 2: true, -tn: true, tn - 2: true}
+	checkCorrelationValues(t, NewKasamiSet(n, PrimitiveTaps(n), true), allowed)
+}
@@ -0,0 +1,251 @@
+package simulation
+
+import (
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// Polynomial over GF(2), bit i holds the coefficient of x^i
+type Polynomial uint64
+
+// Maximum register length covered by the polynomial subsystem
+const MaxPolynomialDegree = 32
+
+// Return the degree of the polynomial, -1 for the zero polynomial
+func (p Polynomial) Degree() int {
+	return bits.Len64(uint64(p)) - 1
+}
+
+// Format the polynomial as e.g. "x^10 + x^3 + 1"
+func (p Polynomial) String() string {
+	if p == 0 {
+		return "0"
+	}
+	var terms []string
+	for e := p.Degree(); e >= 0; e-- {
+		if p&(1<<e) == 0 {
+			continue
+		}
+		switch e {
+		case 0:
+			terms = append(terms, "1")
+		case 1:
+			terms = append(terms, "x")
+		default:
+			terms = append(terms, fmt.Sprintf("x^%d", e))
+		}
+	}
+	return strings.Join(terms, " + ")
+}
+
+// Return the feedback polynomial realized by an n-bit LFSR with the given taps.
+// The register shifts left and feeds the XOR of the tapped bits into bit 0, so
+// bit t holds the output from t+1 steps ago and contributes the term x^(n-1-t).
+// Taps listed twice cancel out, just like in the XOR of LFSR.Feedback.
+func TapsToPolynomial(n uint, taps []uint) Polynomial {
+	p := Polynomial(1) << n
+	for _, t := range taps {
+		if t < n {
+			p ^= 1 << (n - 1 - t)
+		}
+	}
+	return p
+}
+
+// Return the LFSR taps realizing the polynomial, the inverse of TapsToPolynomial
+func PolynomialToTaps(p Polynomial) []uint {
+	n := p.Degree()
+	var taps []uint
+	for t := 0; t < n; t++ {
+		if p&(1<<(n-1-t)) != 0 {
+			taps = append(taps, uint(t))
+		}
+	}
+	return taps
+}
+
+// Report whether the polynomial is primitive over GF(2), i.e. an LFSR using it
+// generates a maximal-length sequence of period 2^n - 1 from every non-zero seed
+func (p Polynomial) IsPrimitive() bool {
+	n := p.Degree()
+	if n < 1 || n > MaxPolynomialDegree || p&1 == 0 {
+		return false
+	}
+	if n == 1 {
+		return true // x + 1
+	}
+	// x has order 2^n - 1 modulo p exactly when p is primitive
+	order := uint64(1)<<n - 1
+	if polyPowMod(2, order, p) != 1 {
+		return false
+	}
+	for _, q := range primeFactors(order) {
+		if polyPowMod(2, order/q, p) == 1 {
+			return false
+		}
+	}
+	return true
+}
+
+// Multiply a and b modulo m, all of degree below m's degree (at most 63)
+func polyMulMod(a, b, m Polynomial) Polynomial {
+	n := m.Degree()
+	var result Polynomial
+	for b != 0 {
+		if b&1 != 0 {
+			result ^= a
+		}
+		b >>= 1
+		a <<= 1
+		if a&(1<<n) != 0 {
+			a ^= m
+		}
+	}
+	return result
+}
+
+// Compute base^exp modulo m
+func polyPowMod(base Polynomial, exp uint64, m Polynomial) Polynomial {
+	result := Polynomial(1)
+	for exp > 0 {
+		if exp&1 != 0 {
+			result = polyMulMod(result, base, m)
+		}
+		base = polyMulMod(base, base, m)
+		exp >>= 1
+	}
+	return result
+}
+
+// Distinct prime factors of v by trial division (v < 2^32)
+func primeFactors(v uint64) []uint64 {
+	var factors []uint64
+	for d := uint64(2); d*d <= v; d++ {
+		if v%d == 0 {
+			factors = append(factors, d)
+			for v%d == 0 {
+				v /= d
+			}
+		}
+	}
+	if v > 1 {
+		factors = append(factors, v)
+	}
+	return factors
+}
+
+// One primitive polynomial per register length n = 2..32
+var primitiveCatalog = map[uint]Polynomial{
+	2:  1<<2 | 1<<1 | 1,
+	3:  1<<3 | 1<<1 | 1,
+	4:  1<<4 | 1<<1 | 1,
+	5:  1<<5 | 1<<2 | 1,
+	6:  1<<6 | 1<<1 | 1,
+	7:  1<<7 | 1<<1 | 1,
+	8:  1<<8 | 1<<4 | 1<<3 | 1<<2 | 1,
+	9:  1<<9 | 1<<4 | 1,
+	10: 1<<10 | 1<<3 | 1,
+	11: 1<<11 | 1<<2 | 1,
+	12: 1<<12 | 1<<6 | 1<<4 | 1<<1 | 1,
+	13: 1<<13 | 1<<4 | 1<<3 | 1<<1 | 1,
+	14: 1<<14 | 1<<10 | 1<<6 | 1<<1 | 1,
+	15: 1<<15 | 1<<1 | 1,
+	16: 1<<16 | 1<<12 | 1<<3 | 1<<1 | 1,
+	17: 1<<17 | 1<<3 | 1,
+	18: 1<<18 | 1<<7 | 1,
+	19: 1<<19 | 1<<5 | 1<<2 | 1<<1 | 1,
+	20: 1<<20 | 1<<3 | 1,
+	21: 1<<21 | 1<<2 | 1,
+	22: 1<<22 | 1<<1 | 1,
+	23: 1<<23 | 1<<5 | 1,
+	24: 1<<24 | 1<<7 | 1<<2 | 1<<1 | 1,
+	25: 1<<25 | 1<<3 | 1,
+	26: 1<<26 | 1<<6 | 1<<2 | 1<<1 | 1,
+	27: 1<<27 | 1<<5 | 1<<2 | 1<<1 | 1,
+	28: 1<<28 | 1<<3 | 1,
+	29: 1<<29 | 1<<2 | 1,
+	30: 1<<30 | 1<<23 | 1<<2 | 1<<1 | 1,
+	31: 1<<31 | 1<<3 | 1,
+	32: 1<<32 | 1<<22 | 1<<2 | 1<<1 | 1,
+}
+
+// Return the catalog primitive polynomial of degree n
+func PrimitivePolynomial(n uint) (Polynomial, bool) {
+	p, ok := primitiveCatalog[n]
+	return p, ok
+}
+
+// Return LFSR taps generating an m-sequence of length 2^n - 1, nil if n is outside the catalog
+func PrimitiveTaps(n uint) []uint {
+	p, ok := PrimitivePolynomial(n)
+	if !ok {
+		return nil
+	}
+	return PolynomialToTaps(p)
+}
+
+// Enumerate up to limit primitive polynomials of degree n in increasing order (limit < 1 lists all).
+// Only practical for small n, the search visits every polynomial of the degree.
+func FindPrimitivePolynomials(n uint, limit int) []Polynomial {
+	if n < 2 || n > MaxPolynomialDegree {
+		return nil
+	}
+	var found []Polynomial
+	for p := Polynomial(1)<<n | 1; p < Polynomial(1)<<(n+1); p += 2 {
+		if p.IsPrimitive() {
+			found = append(found, p)
+			if limit > 0 && len(found) >= limit {
+				break
+			}
+		}
+	}
+	return found
+}
+
+// Result of checking the taps of an LFSR
+type TapsCheck struct {
+	N          uint
+	Taps       []uint
+	Polynomial Polynomial
+	Primitive  bool
+	MaxPeriod  int // 2^n - 1
+	Period     int // Period actually obtained from the checked seed
+}
+
+// Check whether the taps give a maximal-length sequence and measure the period obtained from seed
+func CheckTaps(n uint, taps []uint, seed uint64) TapsCheck {
+	p := TapsToPolynomial(n, taps)
+	check := TapsCheck{
+		N:          n,
+		Taps:       taps,
+		Polynomial: p,
+		Primitive:  p.IsPrimitive(),
+		MaxPeriod:  1<<n - 1,
+	}
+	if check.Primitive {
+		check.Period = check.MaxPeriod
+	} else {
+		check.Period = LFSRPeriod(n, taps, seed)
+	}
+	return check
+}
+
+// Measure the period of the state sequence of an LFSR started from seed (Brent's cycle detection).
+// States that are not on a cycle first run through a transient, which is not counted.
+func LFSRPeriod(n uint, taps []uint, seed uint64) int {
+	lfsr := NewLFSR(seed, taps, n)
+	power, period := 1, 1
+	tortoise := lfsr.state
+	lfsr.Shift()
+	for lfsr.state != tortoise {
+		if power == period {
+			tortoise = lfsr.state
+			power *= 2
+			period = 0
+		}
+		lfsr.Shift()
+		period++
+	}
+	return period
+}
@@ -0,0 +1,150 @@
+package simulation
+
+import "math/bits"
+
+// Largest register length searched by FindPreferredPairs. Every pair costs O(N^2 / 64)
+// word operations, so longer registers are only checked one pair at a time.
+const MaxPreferredPairSearchDegree = 11
+
+// Two primitive polynomials whose m-sequences form a preferred pair
+type PreferredPair struct {
+	Poly1 Polynomial
+	Poly2 Polynomial
+	Taps1 []uint
+	Taps2 []uint
+}
+
+// Number of cyclic shifts at which the cross-correlation takes a given value
+type CorrelationCount struct {
+	Value int // Unnormalized, in -N..N
+	Count int
+}
+
+// Return t(n) = 1 + 2^floor((n+2)/2), the bound on the cross-correlation of a preferred pair
+func PreferredPairBound(n uint) int {
+	return 1 + 1<<((n+2)/2)
+}
+
+// Return the three cross-correlation values of a preferred pair: -1, -t(n) and t(n) - 2
+func PreferredPairValues(n uint) []int {
+	t := PreferredPairBound(n)
+	return []int{-t, -1, t - 2}
+}
+
+// Generate one period of the m-sequence of a primitive polynomial (LFSR seeded with 1)
+func MSequence(p Polynomial) *BitSequence {
+	return NewMSequenceCode(uint(p.Degree()), PolynomialToTaps(p), 1).Chips()
+}
+
+// Compute the periodic cross-correlation of two equally long sequences at every cyclic shift
+// (bits mapped to ±1) and return how often each value occurs, sorted by value
+func CrossCorrelationSpectrum(a, b *BitSequence) []CorrelationCount {
+	if a.length != b.length {
+		panic("Sequences must have the same length")
+	}
+	doubled := doubledWords(b)
+	values := make([]int, a.length)
+	for shift := range a.length {
+		values[shift] = cyclicCorrelation(a, doubled, shift)
+	}
+	return CorrelationHistogram(values)
+}
+
+// Pack two periods of the sequence back to back (plus a spare word), so that any
+// window of up to 64 bits starting in the first period spans at most two words
+func doubledWords(b *BitSequence) []uint64 {
+	words := make([]uint64, (2*b.length+63)/64+1)
+	for i := range 2 * b.length {
+		if b.Get(i%b.length) == 1 {
+			words[i/64] |= 1 << (i % 64)
+		}
+	}
+	return words
+}
+
+// Correlation of a with the doubled b cyclically shifted left by shift: agreements minus disagreements
+func cyclicCorrelation(a *BitSequence, doubled []uint64, shift int) int {
+	disagreements := 0
+	for word := range a.bits {
+		start := word*64 + shift
+		w, offset := start/64, start%64
+		rotated := doubled[w] >> offset
+		if offset > 0 {
+			rotated |= doubled[w+1] << (64 - offset)
+		}
+		width := min(64, a.length-word*64)
+		disagreements += bits.OnesCount64((a.bits[word] ^ rotated) & (1<<width - 1))
+	}
+	return a.length - 2*disagreements
+}
+
+// Report whether the m-sequences of two primitive polynomials of the same degree form a
+// preferred pair, i.e. their cross-correlation only takes the values -1, -t(n) and t(n) - 2.
+// Preferred pairs do not exist for n divisible by 4.
+func IsPreferredPair(p1, p2 Polynomial) (bool, []CorrelationCount) {
+	n := p1.Degree()
+	if n != p2.Degree() || p1 == p2 || !p1.IsPrimitive() || !p2.IsPrimitive() {
+		return false, nil
+	}
+	spectrum := CrossCorrelationSpectrum(MSequence(p1), MSequence(p2))
+	return isPreferredSpectrum(uint(n), spectrum), spectrum
+}
+
+func isPreferredSpectrum(n uint, spectrum []CorrelationCount) bool {
+	return n%4 != 0 && ThreeValued(n, spectrum)
+}
+
+// Report whether a correlation spectrum of codes of length 2^n - 1 only takes the values
+// -1, -t(n) and t(n) - 2, as the cross-correlation of any two Gold codes of a preferred pair
+// and their off-peak autocorrelation do
+func ThreeValued(n uint, spectrum []CorrelationCount) bool {
+	allowed := PreferredPairValues(n)
+	for _, c := range spectrum {
+		if c.Value != allowed[0] && c.Value != allowed[1] && c.Value != allowed[2] {
+			return false
+		}
+	}
+	return true
+}
+
+// Enumerate the primitive polynomials of degree n and return up to limit preferred pairs
+// (limit < 1 returns all). Only the first polynomial of a pair is searched from if first != 0.
+func FindPreferredPairs(n uint, first Polynomial, limit int) []PreferredPair {
+	if n < 2 || n > MaxPreferredPairSearchDegree || n%4 == 0 {
+		return nil
+	}
+	polys := FindPrimitivePolynomials(n, 0)
+	seqs := make([]*BitSequence, len(polys))
+	for i, p := range polys {
+		seqs[i] = MSequence(p)
+	}
+
+	var pairs []PreferredPair
+	for i := range polys {
+		if first != 0 && polys[i] != first {
+			continue
+		}
+		start := i + 1
+		if first != 0 {
+			start = 0
+		}
+		for j := start; j < len(polys); j++ {
+			if i == j {
+				continue
+			}
+			if !isPreferredSpectrum(n, CrossCorrelationSpectrum(seqs[i], seqs[j])) {
+				continue
+			}
+			pairs = append(pairs, PreferredPair{
+				Poly1: polys[i],
+				Poly2: polys[j],
+				Taps1: PolynomialToTaps(polys[i]),
+				Taps2: PolynomialToTaps(polys[j]),
+			})
+			if limit > 0 && len(pairs) >= limit {
+				return pairs
+			}
+		}
+	}
+	return pairs
+}
@@ -0,0 +1,69 @@
+package simulation
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// Return the distinct values of a correlation spectrum, sorted
+func spectrumValues(spectrum []CorrelationCount) []int {
+	values := make([]int, len(spectrum))
+	for i, c := range spectrum {
+		values[i] = c.Value
+	}
+	return values
+}
+
+func TestFindPreferredPairsThreeValued(t *testing.T) {
+	for _, n := range []uint{3, 5, 6, 7, 9, 10} {
+		t.Run(fmt.Sprintf("n=%d", n), func(t *testing.T) {
+			pairs := FindPreferredPairs(n, 0, 4)
+			if len(pairs) == 0 {
+				t.Fatalf("no preferred pair found")
+			}
+			for _, pair := range pairs {
+				preferred, spectrum := IsPreferredPair(pair.Poly1, pair.Poly2)
+				if !preferred {
+					t.Fatalf("%v and %v are not reported as a preferred pair", pair.Poly1, pair.Poly2)
+				}
+				// The cross-correlation takes all of -t(n), -1 and t(n) - 2 and nothing else
+				if got, want := spectrumValues(spectrum), PreferredPairValues(n); !slices.Equal(got, want) {
+					t.Fatalf("%v and %v: cross-correlation values %v, want %v", pair.Poly1, pair.Poly2, got, want)
+				}
+				total := 0
+				for _, c := range spectrum {
+					total += c.Count
+				}
+				if total != pow2(n)-1 {
+					t.Fatalf("spectrum covers %d shifts, want %d", total, pow2(n)-1)
+				}
+			}
+		})
+	}
+}
+
+func TestFindPreferredPairsFirstPolynomial(t *testing.T) {
+	first := TapsToPolynomial(5, PrimitiveTaps(5))
+	pairs := FindPreferredPairs(5, first, 0)
+	if len(pairs) == 0 {
+		t.Fatalf("no preferred pair found for %v", first)
+	}
+	for _, pair := range pairs {
+		if pair.Poly1 != first {
+			t.Fatalf("pair %v, %v does not start with %v", pair.Poly1, pair.Poly2, first)
+		}
+	}
+}
+
+func TestNoPreferredPairsForMultiplesOfFour(t *testing.T) {
+	for _, n := range []uint{4, 8} {
+		if pairs := FindPreferredPairs(n, 0, 0); pairs != nil {
+			t.Fatalf("n=%d: found %d preferred pairs, none exist", n, len(pairs))
+		}
+	}
+	p := TapsToPolynomial(5, PrimitiveTaps(5))
+	if preferred, _ := IsPreferredPair(p, p); preferred {
+		t.Fatalf("a polynomial forms a preferred pair with itself")
+	}
+}
@@ -5,17 +5,17 @@ import (
 	"strings"
 )
 
-// Create a random BitSequence of length N
-func RandomSequence(N int) *BitSequence {
+// Create a random BitSequence of length N drawing from rng
+func RandomSequence(N int, rng *rand.Rand) *BitSequence {
 	seq := NewBitSequence(N)
 	for i := range N {
-		seq.Set(i, uint8(rand.Intn(2)))
+		seq.Set(i, uint8(rng.Intn(2)))
 	}
 	return seq
 }
 
-// Create a random BitSequence of length N composed of ascii characters
-func RandomText(N int) string {
+// Create a random BitSequence of length N composed of ascii characters drawn from rng
+func RandomText(N int, rng *rand.Rand) string {
 	numChars := N / 8
 	if numChars == 0 {
 		return ""
@@ -23,7 +23,7 @@ func RandomText(N int) string {
 	var sb strings.Builder
 	sb.Grow(numChars) // Pre-allocate memory for efficiency
 	for range numChars {
-		randomCharByte := byte(rand.Intn(122-97) + 97)
+		randomCharByte := byte(rng.Intn(122-97) + 97)
 		sb.WriteByte(randomCharByte)
 	}
 
@@ -32,14 +32,5 @@ func RandomText(N int) string {
 
 // Convert a string into its ASCII bit sequence
 func StringAsSequence(s string) *BitSequence {
-	N := len(s) * 8
-	seq := NewBitSequence(N)
-
-	for i, ch := range []byte(s) {
-		for j := range 8 {
-			bit := (ch >> (7 - j)) & 1
-			seq.Set(i*8+j, bit)
-		}
-	}
-	return seq
+	return BitSequenceFromBytes([]byte(s))
 }
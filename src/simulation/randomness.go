@@ -0,0 +1,409 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"math/cmplx"
+)
+
+// Significance level of the randomness tests, a sequence passes a test when p >= RandomnessAlpha
+const RandomnessAlpha = 0.01
+
+// Identifiers of the implemented tests of NIST SP 800-22. The serial and cumulative sums tests
+// give two p-values each and are reported as two results.
+const (
+	TestFrequency          = "frequency"
+	TestBlockFrequency     = "block-frequency"
+	TestRuns               = "runs"
+	TestLongestRun         = "longest-run"
+	TestSpectral           = "spectral"
+	TestSerial1            = "serial-1"
+	TestSerial2            = "serial-2"
+	TestApproximateEntropy = "approximate-entropy"
+	TestCumulativeSumsFwd  = "cumulative-sums-forward"
+	TestCumulativeSumsBwd  = "cumulative-sums-backward"
+)
+
+const (
+	minRandomnessLength  = 100  // Recommended minimum of most tests
+	minLongestRunLength  = 128  // Smallest length of the longest run test tables
+	minSpectralLength    = 1000 // Recommended minimum of the spectral test
+	maxSerialBlockBits   = 16   // Upper bound of the serial test block length
+	maxApproxEntropyBits = 10   // Upper bound of the approximate entropy block length
+)
+
+// Result of a single statistical test of a bit sequence
+type RandomnessTestResult struct {
+	Test       string // One of the Test* identifiers
+	Name       string // Name used in NIST SP 800-22
+	PValue     float64
+	Passed     bool
+	Applicable bool   // False when the sequence is too short for the test, PValue is then 0
+	Parameters string // Block lengths and similar parameters chosen for the sequence length
+}
+
+// Run the frequency, block frequency, runs, longest run, spectral DFT, serial, approximate entropy
+// and cumulative sums tests of NIST SP 800-22 on a sequence. Parameters that the specification
+// leaves to the user are derived from the sequence length within its recommendations.
+func RunRandomnessTests(seq *BitSequence) []RandomnessTestResult {
+	serial1, serial2 := SerialTest(seq)
+	forward, backward := CumulativeSumsTest(seq)
+	return []RandomnessTestResult{
+		FrequencyTest(seq),
+		BlockFrequencyTest(seq),
+		RunsTest(seq),
+		LongestRunTest(seq),
+		SpectralTest(seq),
+		serial1,
+		serial2,
+		ApproximateEntropyTest(seq),
+		forward,
+		backward,
+	}
+}
+
+// Return the number of passed and applicable tests
+func CountPassed(results []RandomnessTestResult) (int, int) {
+	passed, applicable := 0, 0
+	for _, result := range results {
+		if result.Applicable {
+			applicable++
+			if result.Passed {
+				passed++
+			}
+		}
+	}
+	return passed, applicable
+}
+
+func newRandomnessResult(test, name string, p float64, parameters string) RandomnessTestResult {
+	p = min(max(p, 0), 1)
+	return RandomnessTestResult{
+		Test:       test,
+		Name:       name,
+		PValue:     p,
+		Passed:     p >= RandomnessAlpha,
+		Applicable: true,
+		Parameters: parameters,
+	}
+}
+
+func notApplicable(test, name, parameters string) RandomnessTestResult {
+	return RandomnessTestResult{Test: test, Name: name, Parameters: parameters}
+}
+
+// Frequency (monobit) test: the proportion of ones should be close to 1/2
+func FrequencyTest(seq *BitSequence) RandomnessTestResult {
+	const name = "Frequency (Monobit)"
+	n := seq.length
+	if n < minRandomnessLength {
+		return notApplicable(TestFrequency, name, "n < 100")
+	}
+	sum := 2*ones(seq, 0, n) - n
+	sObs := math.Abs(float64(sum)) / math.Sqrt(float64(n))
+	return newRandomnessResult(TestFrequency, name, math.Erfc(sObs/math.Sqrt2), "")
+}
+
+// Block frequency test: the proportion of ones in every M-bit block should be close to 1/2.
+// M is at least 20 and large enough for at most 99 blocks.
+func BlockFrequencyTest(seq *BitSequence) RandomnessTestResult {
+	const name = "Frequency within a Block"
+	n := seq.length
+	if n < minRandomnessLength {
+		return notApplicable(TestBlockFrequency, name, "n < 100")
+	}
+	m := max(20, n/99+1)
+	return newRandomnessResult(TestBlockFrequency, name, blockFrequencyPValue(seq, m), fmt.Sprintf("M = %d, N = %d", m, n/m))
+}
+
+func blockFrequencyPValue(seq *BitSequence, m int) float64 {
+	blocks := seq.length / m
+	chi2 := 0.0
+	for i := range blocks {
+		pi := float64(ones(seq, i*m, m))/float64(m) - 0.5
+		chi2 += pi * pi
+	}
+	chi2 *= 4 * float64(m)
+	return igamc(float64(blocks)/2, chi2/2)
+}
+
+// Runs test: the number of runs of identical bits should match a random sequence with the
+// same proportion of ones. A proportion far from 1/2 already fails the test with p = 0.
+func RunsTest(seq *BitSequence) RandomnessTestResult {
+	const name = "Runs"
+	n := seq.length
+	if n < minRandomnessLength {
+		return notApplicable(TestRuns, name, "n < 100")
+	}
+	pi := float64(ones(seq, 0, n)) / float64(n)
+	if math.Abs(pi-0.5) >= 2/math.Sqrt(float64(n)) {
+		return newRandomnessResult(TestRuns, name, 0, fmt.Sprintf("π = %.4f", pi))
+	}
+	// Every change between neighbouring bits starts a new run
+	runs := 1 + seq.Slice(0, n-1).HammingDistance(seq.Slice(1, n))
+	expected := 2 * float64(n) * pi * (1 - pi)
+	p := math.Erfc(math.Abs(float64(runs)-expected) / (2 * math.Sqrt(2*float64(n)) * pi * (1 - pi)))
+	return newRandomnessResult(TestRuns, name, p, fmt.Sprintf("V = %d", runs))
+}
+
+// Categories of the longest run test for a block length: the longest run of every block is
+// clamped to [low, low+len(probabilities)-1] and counted against the probabilities
+type longestRunTable struct {
+	blockLength   int
+	low           int
+	probabilities []float64
+}
+
+// Probabilities of the NIST reference implementation, the specification rounds them to four digits
+var longestRunTables = []longestRunTable{
+	{8, 1, []float64{0.21484375, 0.3671875, 0.23046875, 0.1875}},
+	{128, 4, []float64{0.1174035788, 0.242955959, 0.249363483, 0.17517706, 0.102701071, 0.112398847}},
+	{10000, 10, []float64{0.0882, 0.2092, 0.2483, 0.1933, 0.1208, 0.0675, 0.0727}},
+}
+
+// Longest run of ones in a block test, with M = 8, 128 or 10^4 depending on the sequence length
+func LongestRunTest(seq *BitSequence) RandomnessTestResult {
+	const name = "Longest Run of Ones in a Block"
+	n := seq.length
+	if n < minLongestRunLength {
+		return notApplicable(TestLongestRun, name, "n < 128")
+	}
+	table := longestRunTables[0]
+	if n >= 750000 {
+		table = longestRunTables[2]
+	} else if n >= 6272 {
+		table = longestRunTables[1]
+	}
+	return newRandomnessResult(TestLongestRun, name, longestRunPValue(seq, table), fmt.Sprintf("M = %d, N = %d", table.blockLength, n/table.blockLength))
+}
+
+func longestRunPValue(seq *BitSequence, table longestRunTable) float64 {
+	m := table.blockLength
+	k := len(table.probabilities)
+	blocks := seq.length / m
+	counts := make([]int, k)
+	for i := range blocks {
+		longest, run := 0, 0
+		for j := range m {
+			if seq.Get(i*m+j) == 1 {
+				run++
+				longest = max(longest, run)
+			} else {
+				run = 0
+			}
+		}
+		counts[min(max(longest-table.low, 0), k-1)]++
+	}
+	chi2 := 0.0
+	for i, p := range table.probabilities {
+		expected := float64(blocks) * p
+		diff := float64(counts[i]) - expected
+		chi2 += diff * diff / expected
+	}
+	return igamc(float64(k-1)/2, chi2/2)
+}
+
+// Spectral (discrete Fourier transform) test: 95% of the DFT peaks of the ±1 sequence
+// should stay below the threshold sqrt(n ln 20), periodic features push them above it
+func SpectralTest(seq *BitSequence) RandomnessTestResult {
+	const name = "Discrete Fourier Transform (Spectral)"
+	n := seq.length
+	if n < minSpectralLength {
+		return notApplicable(TestSpectral, name, "n < 1000")
+	}
+	p, below, expected := spectralPValue(seq)
+	return newRandomnessResult(TestSpectral, name, p, fmt.Sprintf("N1 = %d, N0 = %.1f", below, expected))
+}
+
+// Return the p-value, the number of peaks below the threshold and its expected value
+func spectralPValue(seq *BitSequence) (float64, int, float64) {
+	n := seq.length
+	x := make([]complex128, n)
+	for i := range n {
+		x[i] = complex(float64(2*int(seq.Get(i))-1), 0)
+	}
+	spectrum := DFT(x)
+	threshold := math.Sqrt(math.Log(1/0.05) * float64(n))
+	below := 0
+	for j := range n / 2 {
+		if cmplx.Abs(spectrum[j]) < threshold {
+			below++
+		}
+	}
+	expected := 0.95 * float64(n) / 2
+	d := (float64(below) - expected) / math.Sqrt(float64(n)*0.95*0.05/4)
+	return math.Erfc(math.Abs(d) / math.Sqrt2), below, expected
+}
+
+// Serial test: all 2^m overlapping m-bit patterns should be equally frequent.
+// Returns the p-values of the first and second differences of ψ², m = floor(log2 n) - 3.
+func SerialTest(seq *BitSequence) (RandomnessTestResult, RandomnessTestResult) {
+	const name1, name2 = "Serial (∇ψ²)", "Serial (∇²ψ²)"
+	n := seq.length
+	if n < minRandomnessLength {
+		return notApplicable(TestSerial1, name1, "n < 100"), notApplicable(TestSerial2, name2, "n < 100")
+	}
+	m := min(bits.Len(uint(n))-4, maxSerialBlockBits)
+	p1, p2 := serialPValues(seq, m)
+	parameters := fmt.Sprintf("m = %d", m)
+	return newRandomnessResult(TestSerial1, name1, p1, parameters), newRandomnessResult(TestSerial2, name2, p2, parameters)
+}
+
+// Return both p-values of the serial test with blocks of m >= 3 bits
+func serialPValues(seq *BitSequence, m int) (float64, float64) {
+	n := seq.length
+	psi := func(m int) float64 {
+		if m <= 0 {
+			return 0
+		}
+		sum := 0.0
+		for _, count := range patternCounts(seq, m) {
+			sum += float64(count) * float64(count)
+		}
+		return sum*float64(pow2(uint(m)))/float64(n) - float64(n)
+	}
+	psiM, psiM1, psiM2 := psi(m), psi(m-1), psi(m-2)
+	p1 := igamc(float64(pow2(uint(m-2))), (psiM-psiM1)/2)
+	p2 := igamc(float64(pow2(uint(m-3))), (psiM-2*psiM1+psiM2)/2)
+	return p1, p2
+}
+
+// Approximate entropy test: compares the frequencies of overlapping m-bit and (m+1)-bit patterns,
+// m = floor(log2 n) - 6, at least 1
+func ApproximateEntropyTest(seq *BitSequence) RandomnessTestResult {
+	const name = "Approximate Entropy"
+	n := seq.length
+	if n < minRandomnessLength {
+		return notApplicable(TestApproximateEntropy, name, "n < 100")
+	}
+	m := min(max(bits.Len(uint(n))-7, 1), maxApproxEntropyBits)
+	return newRandomnessResult(TestApproximateEntropy, name, approximateEntropyPValue(seq, m), fmt.Sprintf("m = %d", m))
+}
+
+func approximateEntropyPValue(seq *BitSequence, m int) float64 {
+	n := seq.length
+	phi := func(m int) float64 {
+		sum := 0.0
+		for _, count := range patternCounts(seq, m) {
+			if count > 0 {
+				c := float64(count) / float64(n)
+				sum += c * math.Log(c)
+			}
+		}
+		return sum
+	}
+	apEn := phi(m) - phi(m+1)
+	chi2 := 2 * float64(n) * (math.Ln2 - apEn)
+	return igamc(float64(pow2(uint(m-1))), chi2/2)
+}
+
+// Cumulative sums test: the maximal excursion of the random walk of ±1 steps, run from the
+// start (forward) and from the end (backward) of the sequence
+func CumulativeSumsTest(seq *BitSequence) (RandomnessTestResult, RandomnessTestResult) {
+	const nameFwd, nameBwd = "Cumulative Sums (Forward)", "Cumulative Sums (Backward)"
+	n := seq.length
+	if n < minRandomnessLength {
+		return notApplicable(TestCumulativeSumsFwd, nameFwd, "n < 100"), notApplicable(TestCumulativeSumsBwd, nameBwd, "n < 100")
+	}
+	excursion := func(backward bool) int {
+		sum, z := 0, 0
+		for i := range n {
+			pos := i
+			if backward {
+				pos = n - 1 - i
+			}
+			sum += 2*int(seq.Get(pos)) - 1
+			z = max(z, abs(sum))
+		}
+		return z
+	}
+	zFwd, zBwd := excursion(false), excursion(true)
+	return newRandomnessResult(TestCumulativeSumsFwd, nameFwd, cusumPValue(n, zFwd), fmt.Sprintf("z = %d", zFwd)),
+		newRandomnessResult(TestCumulativeSumsBwd, nameBwd, cusumPValue(n, zBwd), fmt.Sprintf("z = %d", zBwd))
+}
+
+// P-value of the cumulative sums test for a walk of n steps with maximal excursion z
+func cusumPValue(n, z int) float64 {
+	sqrtN := math.Sqrt(float64(n))
+	fz, fn := float64(z), float64(n)
+	sum1 := 0.0
+	for k := int(math.Floor((-fn/fz + 1) / 4)); k <= int(math.Floor((fn/fz-1)/4)); k++ {
+		sum1 += normalCDF(float64(4*k+1)*fz/sqrtN) - normalCDF(float64(4*k-1)*fz/sqrtN)
+	}
+	sum2 := 0.0
+	for k := int(math.Floor((-fn/fz - 3) / 4)); k <= int(math.Floor((fn/fz-1)/4)); k++ {
+		sum2 += normalCDF(float64(4*k+3)*fz/sqrtN) - normalCDF(float64(4*k+1)*fz/sqrtN)
+	}
+	return 1 - sum1 + sum2
+}
+
+// Count the overlapping m-bit patterns of the sequence extended cyclically by its first m-1 bits
+func patternCounts(seq *BitSequence, m int) []int {
+	n := seq.length
+	counts := make([]int, pow2(uint(m)))
+	mask := pow2(uint(m)) - 1
+	pattern := 0
+	for i := range n + m - 1 {
+		pattern = (pattern<<1 | int(seq.Get(i%n))) & mask
+		if i >= m-1 {
+			counts[pattern]++
+		}
+	}
+	return counts
+}
+
+// Number of ones among the length bits starting at start
+func ones(seq *BitSequence, start, length int) int {
+	return seq.Slice(start, start+length).OnesCount()
+}
+
+// Standard normal cumulative distribution function
+func normalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// Regularized upper incomplete gamma function Q(a, x), the chi-square tail used by the tests.
+// A power series is used below a+1 and a continued fraction above.
+func igamc(a, x float64) float64 {
+	if x <= 0 || a <= 0 {
+		return 1
+	}
+	const eps = 1e-15
+	lgamma, _ := math.Lgamma(a)
+	front := math.Exp(-x + a*math.Log(x) - lgamma)
+	if x < a+1 {
+		term := 1 / a
+		sum := term
+		for ap := a + 1; math.Abs(term) > math.Abs(sum)*eps; ap++ {
+			term *= x / ap
+			sum += term
+		}
+		return 1 - sum*front
+	}
+	// Modified Lentz evaluation of the continued fraction
+	const tiny = 1e-300
+	b := x + 1 - a
+	c := 1 / tiny
+	d := 1 / b
+	h := d
+	for i := 1; i < 1000; i++ {
+		an := -float64(i) * (float64(i) - a)
+		b += 2
+		d = an*d + b
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = b + an/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		delta := d * c
+		h *= delta
+		if math.Abs(delta-1) < eps {
+			break
+		}
+	}
+	return front * h
+}
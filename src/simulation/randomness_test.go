@@ -0,0 +1,44 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+// Example sequences of NIST SP 800-22 Rev. 1a: the first 100 bits of the binary expansion
+// of π (sections 2.1.8, 2.3.8 and 2.13.8) and the 128-bit example of section 2.4.8
+const (
+	nistPiBits         = "1100100100001111110110101010001000100001011010001100001000110100110001001100011001100010100010111000"
+	nistLongestRunBits = "11001100000101010110110001001100111000000000001001001101010100010001001111010110100000001101011111001100111001101101100010110010"
+)
+
+func TestRandomnessNISTExamples(t *testing.T) {
+	pi, err := BitSequenceFromString(nistPiBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	longestRun, err := BitSequenceFromString(nistLongestRunBits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cusumFwd, cusumBwd := CumulativeSumsTest(pi)
+	tests := []struct {
+		result RandomnessTestResult
+		want   float64
+	}{
+		{FrequencyTest(pi), 0.109599},
+		{RunsTest(pi), 0.500798},
+		{cusumFwd, 0.219194},
+		{cusumBwd, 0.114866},
+		{LongestRunTest(longestRun), 0.180609},
+	}
+	for _, test := range tests {
+		if !test.result.Applicable {
+			t.Errorf("%s: not applicable to the example", test.result.Name)
+			continue
+		}
+		if math.Abs(test.result.PValue-test.want) > 1e-6 {
+			t.Errorf("%s: p-value %.6f, NIST SP 800-22 gives %.6f", test.result.Name, test.result.PValue, test.want)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package simulation
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Periodic spreading code with its display metadata. Bit 1 is chip +1 as in BitsToSignal.
+type SpreadingCode interface {
+	Len() int            // Period in chips
+	Chips() *BitSequence // One period of the code
+	Name() string
+	Family() string // One of the CodeFamily constants
+}
+
+// Maximal-length sequence of a single n-bit LFSR started from Seed, period 2^n - 1 for primitive taps.
+// Phase skips that many chips of the sequence using a jump-ahead instead of shifting.
+type MSequenceCode struct {
+	N     uint
+	Taps  []uint
+	Seed  uint64
+	Phase uint64
+}
+
+// Create the m-sequence of an n-bit LFSR with the given taps and initial state
+func NewMSequenceCode(n uint, taps []uint, seed uint64) *MSequenceCode {
+	return &MSequenceCode{N: n, Taps: taps, Seed: seed}
+}
+
+func (m *MSequenceCode) Len() int {
+	return pow2(m.N) - 1
+}
+
+func (m *MSequenceCode) Chips() *BitSequence {
+	lfsr := NewLFSR(m.Seed, m.Taps, m.N).Galois()
+	lfsr.Jump(m.Phase)
+	chips := NewBitSequence(m.Len())
+	for i := range chips.length {
+		chips.Set(i, lfsr.Shift())
+	}
+	return chips
+}
+
+func (m *MSequenceCode) Name() string {
+	if m.Phase != 0 {
+		return fmt.Sprintf("T^%d m%v", m.Phase, m.Taps)
+	}
+	return fmt.Sprintf("m%v", m.Taps)
+}
+
+func (m *MSequenceCode) Family() string {
+	return CodeFamilyMSequence
+}
+
+// Gold code of two n-bit LFSRs started from the given seeds, see GenerateGoldCode
+type GoldCode struct {
+	N     uint
+	Taps1 []uint
+	Seed1 uint64
+	Taps2 []uint
+	Seed2 uint64
+}
+
+// Create the gold code of two n-bit LFSRs with the given taps and initial states
+func NewGoldCode(n uint, taps1 []uint, seed1 uint64, taps2 []uint, seed2 uint64) *GoldCode {
+	return &GoldCode{N: n, Taps1: taps1, Seed1: seed1, Taps2: taps2, Seed2: seed2}
+}
+
+func (g *GoldCode) Len() int {
+	return pow2(g.N) - 1
+}
+
+func (g *GoldCode) Chips() *BitSequence {
+	return GenerateGoldCode(g.N, g.Taps1, g.Seed1, g.Taps2, g.Seed2)
+}
+
+func (g *GoldCode) Name() string {
+	return fmt.Sprintf("u(%d) ⊕ v(%d)", g.Seed1, g.Seed2)
+}
+
+func (g *GoldCode) Family() string {
+	return CodeFamilyGold
+}
+
+// Known Barker codes by length, aperiodic autocorrelation sidelobes are at most 1 in magnitude
+var barkerCodes = map[int]string{
+	2:  "10",
+	3:  "110",
+	4:  "1101",
+	5:  "11101",
+	7:  "1110010",
+	11: "11100010010",
+	13: "1111100110101",
+}
+
+// Barker code of one of the lengths returned by BarkerLengths
+type BarkerCode struct {
+	Length int
+}
+
+// Create the Barker code of the given length, panics if there is none
+func NewBarkerCode(length int) *BarkerCode {
+	if _, ok := barkerCodes[length]; !ok {
+		panic(fmt.Sprintf("No Barker code of length %d", length))
+	}
+	return &BarkerCode{Length: length}
+}
+
+// Return the lengths of the known Barker codes in increasing order
+func BarkerLengths() []int {
+	lengths := make([]int, 0, len(barkerCodes))
+	for length := range barkerCodes {
+		lengths = append(lengths, length)
+	}
+	slices.Sort(lengths)
+	return lengths
+}
+
+func (b *BarkerCode) Len() int {
+	return b.Length
+}
+
+func (b *BarkerCode) Chips() *BitSequence {
+	chips := NewBitSequence(b.Length)
+	for i, c := range barkerCodes[b.Length] {
+		if c == '1' {
+			chips.Set(i, 1)
+		}
+	}
+	return chips
+}
+
+func (b *BarkerCode) Name() string {
+	return fmt.Sprintf("B%d", b.Length)
+}
+
+func (b *BarkerCode) Family() string {
+	return CodeFamilyBarker
+}
+
+// JPL ranging code: XOR of m-sequences with pairwise coprime periods, so the period is the product
+// of the component periods while a receiver can still acquire every short component separately
+type JPLCode struct {
+	Components []*MSequenceCode
+}
+
+// Create the JPL code of the given components, panics if their periods are not pairwise coprime
+func NewJPLCode(components ...*MSequenceCode) *JPLCode {
+	for i, a := range components {
+		for _, b := range components[i+1:] {
+			if gcd(a.Len(), b.Len()) != 1 {
+				panic(fmt.Sprintf("JPL component periods %d and %d are not coprime", a.Len(), b.Len()))
+			}
+		}
+	}
+	return &JPLCode{Components: components}
+}
+
+func (j *JPLCode) Len() int {
+	length := 1
+	for _, component := range j.Components {
+		length *= component.Len()
+	}
+	return length
+}
+
+func (j *JPLCode) Chips() *BitSequence {
+	chips := NewBitSequence(j.Len())
+	for _, component := range j.Components {
+		chips = chips.Xor(component.Chips().Repeat(chips.length))
+	}
+	return chips
+}
+
+func (j *JPLCode) Name() string {
+	periods := make([]string, len(j.Components))
+	for i, component := range j.Components {
+		periods[i] = strconv.Itoa(component.Len())
+	}
+	return "JPL " + strings.Join(periods, "·")
+}
+
+func (j *JPLCode) Family() string {
+	return CodeFamilyJPL
+}
+
+// Code set of the cyclic shifts of a single spreading code, index i is the code advanced by i chips
+type ShiftSet struct {
+	Base  SpreadingCode
+	chips *BitSequence
+}
+
+// Create the code set of all cyclic shifts of the code
+func NewShiftSet(base SpreadingCode) *ShiftSet {
+	return &ShiftSet{Base: base, chips: base.Chips()}
+}
+
+// Return the number of codes, equal to the period
+func (s *ShiftSet) Size() int {
+	return s.chips.length
+}
+
+// Return the base code advanced by index chips
+func (s *ShiftSet) Code(index int) *BitSequence {
+	if index < 0 || index >= s.Size() {
+		panic("Shift set index out of range")
+	}
+	return s.chips.Rotate(index)
+}
+
+// Return the display name of the code with the given index
+func (s *ShiftSet) Name(index int) string {
+	if index == 0 {
+		return s.Base.Name()
+	}
+	return fmt.Sprintf("T^%d %s", index, s.Base.Name())
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,118 @@
+package simulation
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// Synchronization errors of the channel, the receiver clock loses or gains bits so the
+// received sequence is no longer aligned with the transmitted one. Probabilities are fractions.
+type SyncImpairment struct {
+	InsertionRate float64 // Probability of a random bit inserted after every bit
+	DeletionRate  float64 // Probability of every bit being lost
+	SlipPeriod    int     // A clock slip every SlipPeriod bits, 0 disables slips
+	SlipRepeat    bool    // Slips repeat a bit (fast clock) instead of dropping it (slow clock)
+}
+
+// Number of synchronization errors introduced by ApplySyncImpairments
+type SyncStats struct {
+	Insertions int
+	Deletions  int
+	Slips      int
+}
+
+// Return true if the impairment changes any sequence
+func (s SyncImpairment) Active() bool {
+	return s.InsertionRate > 0 || s.DeletionRate > 0 || s.SlipPeriod > 0
+}
+
+// Describe the impairment and its parameters
+func (s SyncImpairment) String() string {
+	if !s.Active() {
+		return "none"
+	}
+	var parts []string
+	if s.InsertionRate > 0 {
+		parts = append(parts, fmt.Sprintf("insertions %.2f%%", s.InsertionRate*100))
+	}
+	if s.DeletionRate > 0 {
+		parts = append(parts, fmt.Sprintf("deletions %.2f%%", s.DeletionRate*100))
+	}
+	if s.SlipPeriod > 0 {
+		kind := "dropped"
+		if s.SlipRepeat {
+			kind = "repeated"
+		}
+		parts = append(parts, fmt.Sprintf("clock slip every %d bits (%s bit)", s.SlipPeriod, kind))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Return the total number of bits added or removed
+func (s SyncStats) Total() int {
+	return s.Insertions + s.Deletions + s.Slips
+}
+
+// Passes the sequence through a channel with synchronization errors and returns the received
+// sequence, which may be shorter or longer than the input. Every SlipPeriod-th bit slips, any
+// other bit is deleted with DeletionRate, and a random bit follows every bit with InsertionRate.
+// At least one bit is always received.
+func ApplySyncImpairments(sequence *BitSequence, impairment SyncImpairment, rng *rand.Rand) (*BitSequence, SyncStats) {
+	received, stats := ApplySyncImpairmentsWithErasures(NewErasureSequence(sequence), impairment, rng)
+	return received.Bits, stats
+}
+
+// ApplySyncImpairments for a sequence with erasures, the erasures move with their bits and
+// inserted bits are never erased
+func ApplySyncImpairmentsWithErasures(sequence *ErasureSequence, impairment SyncImpairment, rng *rand.Rand) (*ErasureSequence, SyncStats) {
+	var stats SyncStats
+	// Received positions as bit | 2 for an erasure
+	received := make([]byte, 0, sequence.Len())
+	for i := range sequence.Len() {
+		position := sequence.Bits.Get(i) | 2*sequence.Erased.Get(i)
+		switch {
+		case impairment.SlipPeriod > 0 && (i+1)%impairment.SlipPeriod == 0:
+			stats.Slips++
+			if impairment.SlipRepeat {
+				received = append(received, position, position)
+			}
+		case rng.Float64() < impairment.DeletionRate:
+			stats.Deletions++
+		default:
+			received = append(received, position)
+		}
+		if rng.Float64() < impairment.InsertionRate {
+			received = append(received, byte(rng.Intn(2)))
+			stats.Insertions++
+		}
+	}
+	if len(received) == 0 {
+		// Everything was lost, the receiver still samples one bit
+		received = append(received, byte(rng.Intn(2)))
+		stats.Insertions++
+	}
+	result := &ErasureSequence{Bits: NewBitSequence(len(received)), Erased: NewBitSequence(len(received))}
+	for i, position := range received {
+		result.Bits.Set(i, position&1)
+		result.Erased.Set(i, position>>1)
+	}
+	return result, stats
+}
+
+// Return the received chips padded with zeros to a multiple of spreadingFactor chips and to
+// at least minChips chips, the receiver samples silence after the end of a shortened transmission.
+// The chips themselves are returned when no padding is needed.
+func PadChips(chips *BitSequence, spreadingFactor int, minChips int) *BitSequence {
+	length := max(chips.length, minChips)
+	length = (length + spreadingFactor - 1) / spreadingFactor * spreadingFactor
+	if length == chips.length {
+		return chips
+	}
+	return Concat(chips, NewBitSequence(length-chips.length))
+}
+
+// PadChips for chips with erasures, the padding is not erased
+func PadErasureChips(chips *ErasureSequence, spreadingFactor int, minChips int) *ErasureSequence {
+	return &ErasureSequence{Bits: PadChips(chips.Bits, spreadingFactor, minChips), Erased: PadChips(chips.Erased, spreadingFactor, minChips)}
+}
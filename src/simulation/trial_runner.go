@@ -0,0 +1,125 @@
+package simulation
+
+import (
+	"context"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+// A single independent Monte Carlo trial. It gets its own RNG stream and returns
+// counters (e.g. error counts per user) that are summed over all trials.
+type TrialFunc func(trial int, rng *rand.Rand) []int
+
+// Runs independent trials on a pool of goroutines.
+// Every trial draws from an RNG stream derived from (Seed, trial index) rather than from
+// the worker that happens to execute it, so the merged counters do not depend on Workers.
+type TrialRunner struct {
+	Workers int
+	Seed    int64
+}
+
+// Create a trial runner, workers < 1 uses one worker per CPU
+func NewTrialRunner(workers int, seed int64) *TrialRunner {
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	return &TrialRunner{Workers: workers, Seed: seed}
+}
+
+// Runs trials first..first+count-1 and returns their summed counters. Once ctx is done no
+// further trial is started and the error of ctx is returned after the running ones finish.
+func (r *TrialRunner) Run(ctx context.Context, first, count int, trial TrialFunc) ([]int, error) {
+	if count < 1 {
+		return nil, nil
+	}
+	results := make([][]int, count)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(r.Workers, count) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				if ctx.Err() != nil {
+					continue
+				}
+				// Each trial writes only its own slot, merging happens after all workers are done
+				results[i] = trial(first+i, TrialRNG(r.Seed, first+i))
+			}
+		}()
+	}
+	for i := 0; i < count && ctx.Err() == nil; i++ {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return mergeCounts(results), nil
+}
+
+// Returns the RNG stream of the given trial
+func TrialRNG(seed int64, trial int) *rand.Rand {
+	return rand.New(rand.NewSource(DeriveSeed(seed, trial)))
+}
+
+// Derives an independent seed for the given stream index from a master seed (splitmix64)
+func DeriveSeed(seed int64, stream int) int64 {
+	z := uint64(seed) + uint64(stream+1)*0x9E3779B97F4A7C15
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return int64(z ^ (z >> 31))
+}
+
+// Element-wise sum of the counters of all trials
+func mergeCounts(results [][]int) []int {
+	var total []int
+	for _, counts := range results {
+		if len(counts) > len(total) {
+			total = append(total, make([]int, len(counts)-len(total))...)
+		}
+		for i, c := range counts {
+			total[i] += c
+		}
+	}
+	return total
+}
+
+// Trial of the CDMA link: dataBits random bits per user (more for users with shorter codes,
+// see CDMALink.BitsPerTrial) over a channel with noise sigma.
+// Counters are the bit errors of every user.
+func CDMATrial(link *CDMALink, dataBits int, noiseSigma float64) TrialFunc {
+	return func(trial int, rng *rand.Rand) []int {
+		return link.RunTrial(dataBits, noiseSigma, rng)
+	}
+}
+
+// Trial of the general encode-corrupt-decode pipeline: random data is encoded with the
+// gold code, corrupted by AddErrors and decoded again. Counters are {bit errors, bits sent}.
+func PipelineTrial(goldCode *BitSequence, dataBits int, errorRate float64, errorType string) TrialFunc {
+	return func(trial int, rng *rand.Rand) []int {
+		data := RandomSequence(dataBits, rng)
+		encoded := EncodeWithGold(*data, *goldCode)
+		corrupted, _ := AddErrors(encoded, errorRate, errorType, rng)
+		decoded := DecodeWithGold(*corrupted, *goldCode)
+		return []int{data.HammingDistance(decoded), dataBits}
+	}
+}
+
+// Trial of the general pipeline in DSSS mode: random data is spread over spreadingFactor chips
+// per bit, corrupted at chip level by AddErrors and despread by majority decision.
+// Counters are {bit errors, bits sent, chip errors}.
+func SpreadingTrial(code *BitSequence, spreadingFactor int, dataBits int, errorRate float64, errorType string) TrialFunc {
+	return func(trial int, rng *rand.Rand) []int {
+		data := RandomSequence(dataBits, rng)
+		chips := SpreadWithCode(data, code, spreadingFactor)
+		corrupted, chipErrors := AddErrors(chips, errorRate, errorType, rng)
+		decoded := DespreadWithCode(corrupted, code, spreadingFactor)
+		return []int{data.HammingDistance(decoded), dataBits, chipErrors}
+	}
+}
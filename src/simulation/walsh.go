@@ -0,0 +1,116 @@
+package simulation
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// Walsh-Hadamard codes of length 2^n: row i of the Sylvester-ordered Hadamard matrix, where
+// entry (i, j) is -1 when i AND j has an odd number of ones. Bit 1 is chip +1 as in BitsToSignal,
+// so every two distinct rows are orthogonal when the users are chip-synchronous.
+type WalshSet struct {
+	N      uint
+	Length int
+}
+
+// Create the set of 2^n Walsh-Hadamard codes of length 2^n
+func NewWalshSet(n uint) *WalshSet {
+	return &WalshSet{N: n, Length: pow2(n)}
+}
+
+// Return the number of codes, equal to the code length
+func (s *WalshSet) Size() int {
+	return s.Length
+}
+
+// Return row index of the Hadamard matrix
+func (s *WalshSet) Code(index int) *BitSequence {
+	if index < 0 || index >= s.Length {
+		panic("Walsh code index out of range")
+	}
+	return WalshCode(s.Length, index)
+}
+
+// Return the display name of the code with the given index
+func (s *WalshSet) Name(index int) string {
+	return fmt.Sprintf("W%d", index)
+}
+
+// Generate row index of the Sylvester Hadamard matrix of the given power-of-two order
+func WalshCode(length, index int) *BitSequence {
+	code := NewBitSequence(length)
+	for j := range length {
+		code.Set(j, uint8(1-bits.OnesCount(uint(index&j))%2))
+	}
+	return code
+}
+
+// Tree of orthogonal variable spreading factor codes with spreading factors 1..2^n.
+// C(1,0) is a single +1 chip, C(2SF,2k) = [C(SF,k) C(SF,k)] and C(2SF,2k+1) = [C(SF,k) -C(SF,k)].
+// Codes of any spreading factors are orthogonal unless one lies on the path from the root to the
+// other, so users with different data rates can share a synchronous link.
+// As a CodeSet the tree holds the 2^n codes of the largest spreading factor.
+type OVSFTree struct {
+	N     uint
+	MaxSF int
+}
+
+// Create the OVSF code tree with spreading factors up to 2^n
+func NewOVSFTree(n uint) *OVSFTree {
+	return &OVSFTree{N: n, MaxSF: pow2(n)}
+}
+
+// Return the number of codes of the largest spreading factor
+func (t *OVSFTree) Size() int {
+	return t.MaxSF
+}
+
+// Return C(MaxSF, index)
+func (t *OVSFTree) Code(index int) *BitSequence {
+	return OVSFCode(t.MaxSF, index)
+}
+
+// Return the display name of C(MaxSF, index)
+func (t *OVSFTree) Name(index int) string {
+	return OVSFName(t.MaxSF, index)
+}
+
+// Return the spreading factor used for a requested one: powers of two up to MaxSF
+// are kept, anything else falls back to MaxSF
+func (t *OVSFTree) SpreadingFactor(sf int) int {
+	if sf < 1 || sf > t.MaxSF || sf&(sf-1) != 0 {
+		return t.MaxSF
+	}
+	return sf
+}
+
+// Generate the OVSF code C(sf, k), sf must be a power of two and 0 <= k < sf
+func OVSFCode(sf, k int) *BitSequence {
+	if sf < 1 || sf&(sf-1) != 0 || k < 0 || k >= sf {
+		panic("Invalid OVSF code")
+	}
+	code := NewBitSequence(sf)
+	code.Set(0, 1)
+	// Walk down the tree along the bits of k, most significant first
+	for length, level := 1, bits.Len(uint(sf))-2; length < sf; length, level = length*2, level-1 {
+		negate := uint8(k >> level & 1)
+		for j := range length {
+			code.Set(length+j, code.Get(j)^negate)
+		}
+	}
+	return code
+}
+
+// Return the display name of C(sf, k)
+func OVSFName(sf, k int) string {
+	return fmt.Sprintf("C(%d,%d)", sf, k)
+}
+
+// Report whether C(sf1, k1) and C(sf2, k2) are not orthogonal, i.e. equal or one is
+// an ancestor of the other in the code tree
+func OVSFConflict(sf1, k1, sf2, k2 int) bool {
+	if sf1 > sf2 {
+		sf1, k1, sf2, k2 = sf2, k2, sf1, k1
+	}
+	return k2>>(bits.Len(uint(sf2))-bits.Len(uint(sf1))) == k1
+}